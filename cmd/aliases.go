@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -24,25 +25,27 @@ func getShellRc() string {
 var aliasCmd = &cobra.Command{
 	Use:   "aliases",
 	Short: "Show shell aliases",
-	Long:  `Show example alias commands to install in your shell-rc file`,
+	Long: `Show alias commands to install in your shell-rc file.
+
+	There is an alias for every profile, the built-in ones and those of the config file.
+	Without the alias a profile can be used with sp -p name.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("# ===START OUTPUT sp aliases===")
 		fmt.Println("# This output can be sourced from your shell-rc file:")
 		fmt.Println("#   sp aliases > \"$HOME/.sp-aliases\"")
 		fmt.Printf("#   echo 'source \"$HOME/.sp-aliases\"' >> %s\n\n", getShellRc())
 
-		fmt.Println(`# Rainbow colours
-alias sp-rainbow="sp color --color-type rotating --rotating-type random --stride-length 15-25"
-
-# Colour JSON depending on values of the field called levelname and have alternating colours if subsequent lines match
-alias sp-json-traffic-levelname='sp color --ignore-case --color-type JSON --json-key levelname --colors INFO.0.255.0,INFO.0.155.0,WARNING.255.128.0,WARNING.155.128.0,ERROR.255.0.0,ERROR.155.0.0'
-alias sp-json-traffic-level='sp color --ignore-case --color-type JSON --json-key level --colors INFO.0.255.0,INFO.0.155.0,WARNING.255.128.0,WARNING.155.128.0,ERROR.255.0.0,ERROR.155.0.0'
-
-# Allow colouring of stoud and stderr differently
-alias sp-stdouterr="sp color"
-
-# Replace epoch occurrences with human readable time
-alias sp-epoch="sp epoch"`)
+		profiles, err := getProfiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			os.Exit(1)
+		}
+		for _, p := range profiles {
+			if p.Description != "" {
+				fmt.Printf("# %s\n", strings.ReplaceAll(p.Description, "\n", "\n# "))
+			}
+			fmt.Printf("alias sp-%s='%s'\n\n", p.Name, strings.ReplaceAll(profileCommand(p), "'", `'\''`))
+		}
 
 		fmt.Println("# ===END OUTPUT sp aliases===")
 
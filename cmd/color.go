@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	c "github.com/pvbouwel/sp/color"
 	jsonwriter "github.com/pvbouwel/sp/json"
+	"github.com/pvbouwel/sp/logfmt"
 	"github.com/spf13/cobra"
 )
 
@@ -28,7 +30,49 @@ var colorCmd = &cobra.Command{
 	Example 2 : Have json color-coded based on key named level
 	sp color --force --color-type JSON --json-key level --colors info.0.255.0,warning.255.128.0,error.255.0.0
 
-	For JSON color-type you can have colour banding if you specify a value multiple times.
+	Example 3 : Use the level nested under log or OpenTelemetry's severity_text if there is no such level
+	sp color --color-type JSON --json-key log.level,attributes.severity_text --ignore-case --colors info.0.255.0,error.255.0.0
+
+	Example 4 : Colour access logs red for 5xx, orange for 4xx and slow requests blue
+	sp color --color-type JSON --json-key status --colors '>=500.255.0.0,>=400.255.128.0,latency_ms>1000.0.0.255'
+
+	For JSON color-type a rule is a value the field must be equal to or key OP value where OP is one of
+	= != ~ (regex) ^= (prefix) > >= < <=. The key can be left out to use --json-key and key? checks that
+	a field exists. Numbers and booleans are compared by their text (e.g. ok=true). The first rule that
+	matches decides the colour.
+
+	For JSON color-type you can have colour banding if you specify a rule multiple times.
+
+	Example 5 : Give all lines of a trace the same color
+	sp color --color-type JSON --json-key trace_id --hash-colors
+
+	Example 6 : The same for plain text lines that contain req=<id>
+	sp color --color-type hash --hash-regex 'req=([0-9a-f]+)'
+
+	Example 7 : Syntax highlight JSON and only color the level field
+	sp color --color-type JSON --json-highlight --colors info.0.255.0,error.255.0.0
+
+	Example 8 : Traffic light colors for plain text logs based on their detected level
+	sp color --color-type level
+
+	Example 9 : The same but only for levels like <warn> and with debug lines in grey
+	sp color --color-type level --level-heuristics '' --level-regex '<(\w+)>' --colors debug.128.128.128,warn.255.128.0,error.255.0.0
+
+	For level color-type --colors are level.R.G.B entries, repeating a level gives alternating colors.
+
+	For logfmt color-type (e.g. ts=1 level=info msg="done") the pairs of a line are treated like the
+	fields of a JSON object so the same --json-key and --colors rules apply.
+
+	Example 10 : Traffic light colors for logfmt with keys in blue and only the level value colored
+	sp color --color-type logfmt --ignore-case --key-color blue --colors info.0.255.0,warn.255.128.0,error.255.0.0
+
+	A color is a + separated list of a color and attributes. A color is a name (e.g. red or bright-red),
+	#rrggbb, an index of the 256 color palette (e.g. 208) or R.G.B. Prefix a color with bg: to use it
+	for the background. Attributes are bold, dim, italic, underline, blink, reverse and strikethrough.
+	In --colors R.G.B takes precedence so 1.2.3.4 is the rule 1 with color 2.3.4.
+
+	Example 11 : Errors white on red and bold, warnings in orange
+	sp color --color-type JSON --ignore-case --colors 'error.bg:red+bright-white+bold,warning.#ff8800'
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
@@ -47,22 +91,6 @@ var colorCmd = &cobra.Command{
 	},
 }
 
-var colourTable map[string]*color.Color = map[string]*color.Color{
-	"red":   color.New(color.FgRed),
-	"green": color.New(color.FgGreen),
-	"white": color.New(color.FgWhite),
-}
-
-func availableColors() []string {
-	var availableColors = make([]string, len(colourTable))
-	var i = 0
-	for c := range colourTable {
-		availableColors[i] = c
-		i += 1
-	}
-	return availableColors
-}
-
 func getWriter(cmd *cobra.Command, outputType outputType) (io.Writer, error) {
 	getFlag := getFlagNameFunc(outputType)
 
@@ -80,7 +108,7 @@ func getWriter(cmd *cobra.Command, outputType outputType) (io.Writer, error) {
 		if err != nil {
 			return nil, err
 		}
-		fgColor, err := getColor(textColor)
+		fgColor, err := c.ParseSpec(textColor)
 		if err != nil {
 			return nil, err
 		}
@@ -90,9 +118,7 @@ func getWriter(cmd *cobra.Command, outputType outputType) (io.Writer, error) {
 		if err != nil {
 			return nil, err
 		}
-		strings.Split(colors, ",")
-		var rotColorStrings = strings.Split(colors, ",")
-		rotColors, err := RGBStringsToColors(rotColorStrings)
+		rotColors, err := specsToColors(strings.Split(colors, ","))
 		if err != nil {
 			return nil, err
 		}
@@ -128,92 +154,237 @@ func getWriter(cmd *cobra.Command, outputType outputType) (io.Writer, error) {
 			strideLen = c.NewRandomStrideLengthFunc(int(i64min), int(i64max))
 		}
 		return c.NewRotatingColor(baseWriter, rotColors, strideLen), nil
-	case fColorTypeJSON:
+	case fColorTypeHash:
 		colors, err := cmd.Flags().GetString(getFlag(fColors))
 		if err != nil {
 			return nil, err
 		}
-		jsonKey, err := cmd.Flags().GetString(getFlag(fJSONKey))
+		palette, err := specsToColors(strings.Split(colors, ","))
+		if err != nil {
+			return nil, err
+		}
+		hashRegex, err := cmd.Flags().GetString(getFlag(fHashRegex))
 		if err != nil {
 			return nil, err
 		}
-		var colorStrings = strings.Split(colors, ",")
-		var jColors = make([]jsonwriter.JSONColor, len(colorStrings))
-		for i, colorString := range colorStrings {
-			colorStringParts := strings.Split(colorString, ".")
-			colorDotParts := len(colorStringParts)
-			if colorDotParts < 4 {
-				return nil, fmt.Errorf("invalid JSON color string should be value.R.G.B got %s", colorString)
+		var re *regexp.Regexp
+		if hashRegex != "" {
+			re, err = regexp.Compile(hashRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", getFlag(fHashRegex), err)
 			}
-			c, err := RGBValuesToColor(colorStringParts[colorDotParts-3 : colorDotParts])
+		}
+		return c.NewHashColor(baseWriter, palette, re), nil
+	case fColorTypeLevel:
+		heuristics, err := getLevelHeuristics(cmd, getFlag)
+		if err != nil {
+			return nil, err
+		}
+		palette, err := getLevelPalette(cmd, getFlag)
+		if err != nil {
+			return nil, err
+		}
+		return c.NewLevelColor(baseWriter, heuristics, palette), nil
+	case fColorTypeLogfmt:
+		var styles logfmt.Styles
+		for _, style := range []struct {
+			flag  string
+			color **color.Color
+		}{{fKeyColor, &styles.Key}, {fValueColor, &styles.Value}} {
+			spec, err := cmd.Flags().GetString(getFlag(style.flag))
 			if err != nil {
-				return nil, fmt.Errorf("invalid JSON color string RGB value got %v from %s", colorStringParts[colorDotParts-3:colorDotParts], colorString)
+				return nil, err
 			}
-			value := strings.Join(colorStringParts[0:colorDotParts-3], ".")
-			jColors[i] = jsonwriter.JSONColor{
-				Key:   jsonKey,
-				Value: value,
-				Color: []*color.Color{c},
+			if spec == "" {
+				continue
 			}
+			*style.color, err = c.ParseSpec(spec)
+			if err != nil {
+				return nil, err
+			}
+		}
+		colourDecider, err := getColourDecider(cmd, getFlag, styles.Key != nil || styles.Value != nil)
+		if err != nil {
+			return nil, err
 		}
-		ignoreCase, err := cmd.Flags().GetBool(fIgnoreCase)
+		return logfmt.NewLogfmtWriter(baseWriter, colourDecider, styles), nil
+	case fColorTypeJSON:
+		highlight, err := cmd.Flags().GetBool(fJSONHighlight)
 		if err != nil {
 			return nil, err
 		}
-		var colourDecider = jsonwriter.NewMapBasedColourDecider(ignoreCase, jColors...)
-		return jsonwriter.NewJSONWriter(baseWriter, &colourDecider), nil
+		colourDecider, err := getColourDecider(cmd, getFlag, highlight)
+		if err != nil {
+			return nil, err
+		}
+		if highlight {
+			return jsonwriter.NewHighlightJSONWriter(baseWriter, colourDecider, jsonwriter.DefaultHighlightStyles()), nil
+		}
+		return jsonwriter.NewJSONWriter(baseWriter, colourDecider), nil
 
 	default:
 		return nil, fmt.Errorf("unknown color type: %s", colorType)
 	}
 }
 
-func RGBValuesToColor(rgbValues []string) (*color.Color, error) {
-	if len(rgbValues) != 3 {
-		return nil, fmt.Errorf("invRGBValuesToColor requires 3 .-separated color values (got %d)", len(rgbValues))
+// getColourDecider creates the configured JSON colour decider. Entries of
+// --colors with a rule (rule.R.G.B) become rules and plain R.G.B entries make
+// up the palette. If optional no decider (nil) is returned when there are no
+// rules for the rules decider.
+func getColourDecider(cmd *cobra.Command, getFlag func(string) string, optional bool) (jsonwriter.ColourDecider, error) {
+	deciderName, err := cmd.Flags().GetString(getFlag(fJSONDecider))
+	if err != nil {
+		return nil, err
 	}
-	r, err := strconv.ParseInt(rgbValues[0], 0, 24)
+	colors, err := cmd.Flags().GetString(getFlag(fColors))
 	if err != nil {
-		return nil, fmt.Errorf("invalid R value: %s", rgbValues[0])
+		return nil, err
+	}
+	jsonKey, err := cmd.Flags().GetString(getFlag(fJSONKey))
+	if err != nil {
+		return nil, err
+	}
+	ignoreCase, err := cmd.Flags().GetBool(fIgnoreCase)
+	if err != nil {
+		return nil, err
+	}
+	hashColors, err := cmd.Flags().GetBool(fHashColors)
+	if err != nil {
+		return nil, err
+	}
+	if hashColors {
+		deciderName = jsonwriter.HashDecider
 	}
-	g, err := strconv.ParseInt(rgbValues[1], 0, 24)
+	var config = jsonwriter.DeciderConfig{
+		Keys:       strings.Split(jsonKey, ","),
+		IgnoreCase: ignoreCase,
+	}
+	for _, jsonPath := range config.Keys {
+		_, err = jsonwriter.ParsePath(jsonPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var colorStrings = strings.Split(colors, ",")
+	//Rules are tried in order for the first path, alternative paths are
+	//only tried for rules that do not name a key.
+	for i, jsonPath := range config.Keys {
+		for _, colorString := range colorStrings {
+			ruleString, clr, err := c.ParseRuleSpec(colorString)
+			if err != nil {
+				return nil, err
+			}
+			if ruleString == "" {
+				if i == 0 {
+					config.Palette = append(config.Palette, clr)
+				}
+				continue
+			}
+			rule, err := jsonwriter.ParseRule(ruleString)
+			if err != nil {
+				return nil, err
+			}
+			if rule.Key == "" {
+				rule.Key = jsonPath
+			} else if i > 0 {
+				continue
+			}
+			rule.Color = []*color.Color{clr}
+			config.Rules = append(config.Rules, rule)
+		}
+	}
+	if optional && deciderName == jsonwriter.RulesDecider && len(config.Rules) == 0 {
+		return nil, nil
+	}
+	return jsonwriter.NewColourDecider(deciderName, config)
+}
+
+// getLevelHeuristics returns the selected level heuristics in order, preceded
+// by the one for --level-regex if given.
+func getLevelHeuristics(cmd *cobra.Command, getFlag func(string) string) ([]c.LevelHeuristic, error) {
+	var heuristics = make([]c.LevelHeuristic, 0)
+	levelRegex, err := cmd.Flags().GetString(getFlag(fLevelRegex))
 	if err != nil {
-		return nil, fmt.Errorf("invalid R value: %s", rgbValues[1])
+		return nil, err
+	}
+	if levelRegex != "" {
+		re, err := regexp.Compile(levelRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", getFlag(fLevelRegex), err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("invalid %s: the level must be captured by a group", getFlag(fLevelRegex))
+		}
+		heuristics = append(heuristics, c.RegexpLevelHeuristic{Regexp: re})
 	}
-	b, err := strconv.ParseInt(rgbValues[2], 0, 24)
+	names, err := cmd.Flags().GetString(getFlag(fLevelHeuristics))
 	if err != nil {
-		return nil, fmt.Errorf("invalid R value: %s", rgbValues[2])
+		return nil, err
 	}
-	return color.RGB(int(r), int(g), int(b)), nil
+	for _, name := range strings.Split(names, ",") {
+		if name == "" {
+			continue
+		}
+		h, err := c.GetLevelHeuristic(name)
+		if err != nil {
+			return nil, err
+		}
+		heuristics = append(heuristics, h)
+	}
+	return heuristics, nil
 }
 
-func RGBStringsToColors(icolors []string) ([]*color.Color, error) {
-	var result = make([]*color.Color, len(icolors))
-	for i, clr := range icolors {
-		rgbValues := strings.Split(clr, ".")
-		c, err := RGBValuesToColor((rgbValues))
+// getLevelPalette parses --colors as level.R.G.B entries. Without --colors the
+// traffic light colors are used.
+func getLevelPalette(cmd *cobra.Command, getFlag func(string) string) (map[c.Level][]*color.Color, error) {
+	colors, err := cmd.Flags().GetString(getFlag(fColors))
+	if err != nil {
+		return nil, err
+	}
+	if !cmd.Flags().Changed(getFlag(fColors)) {
+		colors = trafficLightColors
+	}
+	var palette = make(map[c.Level][]*color.Color)
+	for _, colorString := range strings.Split(colors, ",") {
+		levelString, clr, err := c.ParseRuleSpec(colorString)
 		if err != nil {
-			return nil, fmt.Errorf("invalid RGB value: %s: %s", clr, err)
+			return nil, err
 		}
-		result[i] = c
+		level, ok := c.ParseLevel(levelString)
+		if !ok {
+			return nil, fmt.Errorf("invalid level color string should be level.color got %s", colorString)
+		}
+		palette[level] = append(palette[level], clr)
 	}
-	return result, nil
+	//Fatal is an error as far as the traffic light is concerned
+	if _, ok := palette[c.LevelFatal]; !ok {
+		palette[c.LevelFatal] = palette[c.LevelError]
+	}
+	return palette, nil
 }
 
-func getColor(colorString string) (*color.Color, error) {
-	c, ok := colourTable[colorString]
-	if ok {
-		return c, nil
+// specsToColors parses colour specs (see color.ParseSpec)
+func specsToColors(specs []string) ([]*color.Color, error) {
+	var result = make([]*color.Color, len(specs))
+	for i, spec := range specs {
+		clr, err := c.ParseSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = clr
 	}
-	return nil, fmt.Errorf("unknown color: %s", colorString)
+	return result, nil
 }
 
+// getBaseWriter returns the writer to which a stage must send its output. This
+// is the next stage in the chain or the actual output for the last stage.
 func getBaseWriter(outputType outputType) io.Writer {
 	switch outputType {
 	case stdout:
-		return os.Stdout
+		return downstreamStdoutWriter
 	case stderr:
-		return os.Stderr
+		return downstreamStderrWriter
 	}
 	panic(fmt.Sprintf("Invalid outputType %s", outputType))
 }
@@ -222,6 +393,9 @@ const fColorType = "color-type"
 const fColorTypeSingle = "single"
 const fColorTypeRotating = "rotating"
 const fColorTypeJSON = "JSON"
+const fColorTypeHash = "hash"
+const fColorTypeLevel = "level"
+const fColorTypeLogfmt = "logfmt"
 const fColors = "colors"
 const fColorsRainbow = "230.42.42,255.128.0,250.235.54,121.195.20,72.125.231,75.54.157,112.54.157"
 const fRotatingType = "rotating-type"
@@ -230,6 +404,17 @@ const fRotatingRandom = "random"
 const fRotatingStrideLength = "stride-length"
 const fJSONKey = "json-key"
 const fIgnoreCase = "ignore-case"
+const fJSONDecider = "json-decider"
+const fHashColors = "hash-colors"
+const fJSONHighlight = "json-highlight"
+const fHashRegex = "hash-regex"
+const fLevelHeuristics = "level-heuristics"
+const fLevelRegex = "level-regex"
+const fKeyColor = "key-color"
+const fValueColor = "value-color"
+
+// The colors of the traffic light aliases, alternating shades per level
+const trafficLightColors = "INFO.0.255.0,INFO.0.155.0,WARNING.255.128.0,WARNING.155.128.0,ERROR.255.0.0,ERROR.155.0.0"
 
 var fRotatingTypes = []string{
 	fRotatingFixed,
@@ -239,11 +424,21 @@ var fRotatingTypes = []string{
 var fColorTypes = []string{
 	fColorTypeSingle,
 	fColorTypeRotating,
+	fColorTypeJSON,
+	fColorTypeHash,
+	fColorTypeLevel,
+	fColorTypeLogfmt,
 }
 
 const fTextColor = "text-color"
 
 var colorFlags []outErrStringFlag = []outErrStringFlag{
+	{
+		Name:       fJSONDecider,
+		OutDefault: jsonwriter.RulesDecider,
+		ErrDefault: jsonwriter.RulesDecider,
+		Usage:      fmt.Sprintf("The decider that picks colors for JSON color-type [%s].", strings.Join(jsonwriter.ColourDeciders(), ", ")),
+	},
 	{
 		Name:       fColorType,
 		OutDefault: fColorTypeSingle,
@@ -254,13 +449,13 @@ var colorFlags []outErrStringFlag = []outErrStringFlag{
 		Name:       fTextColor,
 		OutDefault: "white",
 		ErrDefault: "red",
-		Usage:      fmt.Sprintf("The default color to use for text [%s].", strings.Join(availableColors(), ", ")),
+		Usage:      "The default color to use for text, a color spec (e.g. white, bright-red, #ff8800, 208 or bg:red+white+bold)",
 	},
 	{
 		Name:       fColors,
 		OutDefault: fColorsRainbow,
 		ErrDefault: fColorsRainbow,
-		Usage:      "The colors to use for color types with multiple colors. comma separated R.G.B values (0-255)",
+		Usage:      "The colors to use for color types with multiple colors. comma separated color specs (e.g. 255.128.0 or #ff8800), for JSON, logfmt and level rule.color",
 	},
 	{
 		Name:       fRotatingType,
@@ -274,11 +469,41 @@ var colorFlags []outErrStringFlag = []outErrStringFlag{
 		ErrDefault: "2",
 		Usage:      "The length used for strides of colors",
 	},
+	{
+		Name:       fHashRegex,
+		OutDefault: "",
+		ErrDefault: "",
+		Usage:      "For hash color-type the regex whose first capture group (or whole match) is hashed to pick the color of a line (default: the whole line)",
+	},
+	{
+		Name:       fLevelHeuristics,
+		OutDefault: strings.Join(c.DefaultLevelHeuristics(), ","),
+		ErrDefault: strings.Join(c.DefaultLevelHeuristics(), ","),
+		Usage:      fmt.Sprintf("For level color-type the comma separated heuristics that detect the level of a line, tried in order [%s]", strings.Join(c.LevelHeuristics(), ", ")),
+	},
+	{
+		Name:       fLevelRegex,
+		OutDefault: "",
+		ErrDefault: "",
+		Usage:      "For level color-type a regex whose first capture group is the level (e.g. WARN or warning), tried before the heuristics",
+	},
+	{
+		Name:       fKeyColor,
+		OutDefault: "",
+		ErrDefault: "",
+		Usage:      "For logfmt color-type the color of keys, setting it or --value-color colors keys and values separately (e.g. blue or 0.0.255)",
+	},
+	{
+		Name:       fValueColor,
+		OutDefault: "",
+		ErrDefault: "",
+		Usage:      "For logfmt color-type the color of values, setting it or --key-color colors keys and values separately",
+	},
 	{
 		Name:       fJSONKey,
 		OutDefault: "level",
 		ErrDefault: "level",
-		Usage:      "The key of the JSON field that decides the color (default: level)",
+		Usage:      "The key of the JSON (or logfmt) field that decides the color. Nested fields can be addressed with a path (e.g. log.level, attributes[\"service.name\"] or events[0].level), comma separated alternatives are tried in order",
 	},
 }
 
@@ -309,6 +534,8 @@ func init() {
 
 	}
 	colorCmd.Flags().Bool(fIgnoreCase, false, "Whether the casing of values should be ignored during matching")
+	colorCmd.Flags().Bool(fHashColors, false, fmt.Sprintf("For JSON color-type pick colors by hashing the value of the JSON key instead of rules (same as --%s %s)", fJSONDecider, jsonwriter.HashDecider))
+	colorCmd.Flags().Bool(fJSONHighlight, false, "For JSON color-type syntax highlight objects and only color the field that decided")
 	colorCmd.Flags().Bool("force", false, "Whether to force coloring regardless of type of outputstream.")
 
 }
@@ -4,7 +4,15 @@ Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
 package cmd
 
 import (
+	"fmt"
+	"math"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded so --tz works on systems without a time zone database
+	_ "time/tzdata"
 
 	"github.com/pvbouwel/sp/epoch"
 	"github.com/spf13/cobra"
@@ -14,13 +22,198 @@ import (
 var epochCmd = &cobra.Command{
 	Use:   "epoch",
 	Short: "Replace epoch occurrences",
-	Long:  `Replace all epoch occurences in the input`,
+	Long: `Replace all epoch occurences in the input.
+
+	The precision of an epoch is derived from its number of digits:
+	10 for seconds, 13 for milliseconds, 16 for microseconds and 19 for nanoseconds.
+	Seconds can have a fraction (e.g. 1700000000.123). The sub-second part is kept in the output.
+
+	Example 1 : show epochs in local time in a short format
+	sp epoch --tz local --format kitchen
+
+	Example 2 : keep the epoch and add the time in New York
+	sp epoch --tz America/New_York --keep-original
+
+	Example 3 : only replace plausible epochs in the JSON fields ts and time
+	sp epoch --min 2000-01-01 --max now+10y --json-key ts,time
+
+	Digits that are part of a longer alphanumeric token (e.g. order1700000000) are never replaced.
+
+	With --reverse RFC3339/ISO 8601, syslog and Apache common log timestamps are replaced by epochs.
+	Timestamps without time zone are taken to be in --tz. The epoch is in seconds unless the timestamp
+	has sub-second digits, the precision flags limit which precisions are used.
+
+	Example 4 : replace timestamps by epochs in milliseconds
+	sp epoch --reverse --seconds=false
+	`,
 	Run: func(cmd *cobra.Command, args []string) {
-		stdoutWriter = epoch.NewEpoch(os.Stdout)
-		stderrWriter = epoch.NewEpoch(os.Stderr)
+		options, err := getEpochOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			return
+		}
+		stdoutWriter = epoch.NewEpoch(getBaseWriter(stdout), options)
+		stderrWriter = epoch.NewEpoch(getBaseWriter(stderr), options)
 	},
 }
 
+type epochPrecisionFlag struct {
+	Name      string
+	Precision epoch.Precision
+}
+
+var epochPrecisionFlags = []epochPrecisionFlag{
+	{Name: "seconds", Precision: epoch.Seconds},
+	{Name: "milliseconds", Precision: epoch.Milliseconds},
+	{Name: "microseconds", Precision: epoch.Microseconds},
+	{Name: "nanoseconds", Precision: epoch.Nanoseconds},
+}
+
+const fEpochFormat = "format"
+const fEpochTimeZone = "tz"
+const fEpochKeepOriginal = "keep-original"
+const fEpochReverse = "reverse"
+const fEpochMin = "min"
+const fEpochMax = "max"
+
+// Units that can be used in a relative time bound like now+10h
+var relativeBoundUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+}
+
+// Calendar units of a relative time bound like now+10y as years, months and
+// days. They are added as a date since durations overflow after 292 years.
+var relativeBoundDateUnits = map[byte][3]int{
+	'd': {0, 0, 1},
+	'w': {0, 0, 7},
+	'y': {1, 0, 0},
+}
+
+// parseTimeBound parses a date (2000-01-01), an RFC3339 time or a time
+// relative to now (now, now+10y, now-1d). An empty bound is the zero time.
+func parseTimeBound(bound string, now time.Time) (time.Time, error) {
+	if bound == "" {
+		return time.Time{}, nil
+	}
+	if rest, ok := strings.CutPrefix(bound, "now"); ok {
+		if rest == "" {
+			return now, nil
+		}
+		unit, isDuration := relativeBoundUnits[rest[len(rest)-1]]
+		dateUnit, isDate := relativeBoundDateUnits[rest[len(rest)-1]]
+		if !(isDuration || isDate) || len(rest) < 3 || (rest[0] != '+' && rest[0] != '-') {
+			return time.Time{}, fmt.Errorf("invalid relative time %s expected for example now+10y", bound)
+		}
+		amount, err := strconv.ParseInt(rest[1:len(rest)-1], 10, 32)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time %s: %s", bound, err)
+		}
+		if rest[0] == '-' {
+			amount = -amount
+		}
+		if isDate {
+			return now.AddDate(int(amount)*dateUnit[0], int(amount)*dateUnit[1], int(amount)*dateUnit[2]), nil
+		}
+		if amount > math.MaxInt64/int64(unit) || amount < math.MinInt64/int64(unit) {
+			return time.Time{}, fmt.Errorf("invalid relative time %s: too far from now, use days, weeks or years", bound)
+		}
+		return now.Add(time.Duration(amount) * unit), nil
+	}
+	t, err := time.Parse(time.DateOnly, bound)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, bound)
+}
+
+func formatPresetNames() []string {
+	var names = make([]string, 0, len(epoch.FormatPresets))
+	for name := range epoch.FormatPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getLocation(tz string) (*time.Location, error) {
+	if strings.EqualFold(tz, "local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+func getEpochOptions(cmd *cobra.Command) (epoch.Options, error) {
+	var options = epoch.DefaultOptions()
+	var err error
+
+	options.Format, err = cmd.Flags().GetString(fEpochFormat)
+	if err != nil {
+		return options, err
+	}
+	tz, err := cmd.Flags().GetString(fEpochTimeZone)
+	if err != nil {
+		return options, err
+	}
+	options.Location, err = getLocation(tz)
+	if err != nil {
+		return options, fmt.Errorf("invalid time zone %s: %s", tz, err)
+	}
+	options.KeepOriginal, err = cmd.Flags().GetBool(fEpochKeepOriginal)
+	if err != nil {
+		return options, err
+	}
+	options.Reverse, err = cmd.Flags().GetBool(fEpochReverse)
+	if err != nil {
+		return options, err
+	}
+	now := time.Now()
+	for _, bound := range []struct {
+		flag string
+		t    *time.Time
+	}{{fEpochMin, &options.Min}, {fEpochMax, &options.Max}} {
+		boundString, err := cmd.Flags().GetString(bound.flag)
+		if err != nil {
+			return options, err
+		}
+		*bound.t, err = parseTimeBound(boundString, now)
+		if err != nil {
+			return options, err
+		}
+	}
+	jsonKeys, err := cmd.Flags().GetString(fJSONKey)
+	if err != nil {
+		return options, err
+	}
+	if jsonKeys != "" {
+		options.JSONKeys = strings.Split(jsonKeys, ",")
+	}
+
+	options.Precisions = make([]epoch.Precision, 0)
+	for _, precisionFlag := range epochPrecisionFlags {
+		enabled, err := cmd.Flags().GetBool(precisionFlag.Name)
+		if err != nil {
+			return options, err
+		}
+		if enabled {
+			options.Precisions = append(options.Precisions, precisionFlag.Precision)
+		}
+	}
+	return options, nil
+}
+
 func init() {
 	rootCmd.AddCommand(epochCmd)
+
+	for _, precisionFlag := range epochPrecisionFlags {
+		epochCmd.Flags().Bool(precisionFlag.Name, true, fmt.Sprintf("Whether to replace epochs in %s (%d digits)", precisionFlag.Name, precisionFlag.Precision))
+	}
+	epochCmd.Flags().String(fEpochFormat, "", fmt.Sprintf("Go time layout or one of [%s, %s] (default: ISO 8601 keeping sub-second digits)", strings.Join(formatPresetNames(), ", "), epoch.FormatRelative))
+	epochCmd.Flags().String(fEpochTimeZone, "UTC", "Time zone to show times in, an IANA name (e.g. Europe/Brussels) or local")
+	epochCmd.Flags().Bool(fEpochKeepOriginal, false, "Keep the epoch and add the time between brackets")
+	epochCmd.Flags().Bool(fEpochReverse, false, "Replace human readable timestamps by epochs instead")
+	epochCmd.Flags().String(fEpochMin, "", "Only replace epochs from this time on, a date, RFC3339 time or relative to now (e.g. 2000-01-01 or now-10y)")
+	epochCmd.Flags().String(fEpochMax, "", "Only replace epochs up to this time, a date, RFC3339 time or relative to now (e.g. now+10y)")
+	epochCmd.Flags().String(fJSONKey, "", "Comma separated keys of JSON fields to which replacing is limited (default: anywhere)")
 }
@@ -0,0 +1,47 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		bound    string
+		expected time.Time
+	}{
+		{"", time.Time{}},
+		{"now", now},
+		{"now+10s", now.Add(10 * time.Second)},
+		{"now-2h", now.Add(-2 * time.Hour)},
+		{"now-1d", time.Date(2025, 1, 30, 12, 0, 0, 0, time.UTC)},
+		{"now+2w", time.Date(2025, 2, 14, 12, 0, 0, 0, time.UTC)},
+		{"now+300y", time.Date(2325, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"now-1000000y", time.Date(2025-1000000, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"2000-01-01", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		//WHEN a bound is parsed
+		got, err := parseTimeBound(tc.bound, now)
+
+		//THEN it is relative to now without overflowing
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", tc.bound, err)
+		}
+		if !got.Equal(tc.expected) {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.bound, tc.expected, got)
+		}
+	}
+
+	//WHEN bounds are invalid or do not fit a duration THEN they fail
+	for _, bound := range []string{"now+", "now+10", "now*1d", "now+3000000h", "now-3000000h", "now+99999999999y", "yesterday"} {
+		_, err := parseTimeBound(bound, now)
+		if err == nil {
+			t.Errorf("Expected an error for %s", bound)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	jsonwriter "github.com/pvbouwel/sp/json"
+	"github.com/spf13/cobra"
+)
+
+// filterCmd represents the filter command
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Drop lines that are not of interest",
+	Long: `Only keep the lines with a JSON object that matches an expression.
+
+	The expression combines terms with && and ||, && binds stronger. A term compares a field
+	(a key or a path like log.level) using the operators of JSON color rules:
+	= != ~ (regex) ^= (prefix) > >= < <= or checks that it exists with key?
+	A term can also check that a field has one of a list of values: level in (warn,error)
+
+	Lines without JSON objects are let through unless --drop-non-json is given.
+
+	Example 1 : only keep slow warnings and errors
+	sp filter --json 'level in (warn,error) && latency_ms > 200' -- app
+
+	Example 2 : only keep the matching objects of lines with several objects
+	sp filter --json 'status>=500' --objects --drop-non-json -- app
+
+	The filter decides per line so a line is only passed on once it is complete.
+	Lines longer than 1 MiB are passed on without filtering to keep memory usage bounded.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		predicate, options, err := getFilter(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			return
+		}
+		stdoutWriter = jsonwriter.NewFilterWriter(getBaseWriter(stdout), predicate, options)
+		stderrWriter = jsonwriter.NewFilterWriter(getBaseWriter(stderr), predicate, options)
+	},
+}
+
+const fFilterJSON = "json"
+const fFilterObjects = "objects"
+const fFilterDropNonJSON = "drop-non-json"
+
+func getFilter(cmd *cobra.Command) (jsonwriter.Predicate, jsonwriter.FilterOptions, error) {
+	var options jsonwriter.FilterOptions
+	expr, err := cmd.Flags().GetString(fFilterJSON)
+	if err != nil {
+		return jsonwriter.Predicate{}, options, err
+	}
+	if expr == "" {
+		return jsonwriter.Predicate{}, options, errors.New("--json is required")
+	}
+	ignoreCase, err := cmd.Flags().GetBool(fIgnoreCase)
+	if err != nil {
+		return jsonwriter.Predicate{}, options, err
+	}
+	predicate, err := jsonwriter.ParsePredicate(expr, ignoreCase)
+	if err != nil {
+		return predicate, options, err
+	}
+	options.Objects, err = cmd.Flags().GetBool(fFilterObjects)
+	if err != nil {
+		return predicate, options, err
+	}
+	dropNonJSON, err := cmd.Flags().GetBool(fFilterDropNonJSON)
+	if err != nil {
+		return predicate, options, err
+	}
+	options.PassNonJSON = !dropNonJSON
+	return predicate, options, nil
+}
+
+func init() {
+	rootCmd.AddCommand(filterCmd)
+
+	filterCmd.Flags().String(fFilterJSON, "", "Expression that a JSON object of a line must match (e.g. 'level in (warn,error) && latency_ms > 200')")
+	filterCmd.Flags().Bool(fFilterObjects, false, "Only drop the JSON objects that do not match instead of their whole line")
+	filterCmd.Flags().Bool(fFilterDropNonJSON, false, "Drop lines that do not contain a JSON object")
+	filterCmd.Flags().Bool(fIgnoreCase, false, "Compare values without considering case")
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+	"github.com/spf13/cobra"
+)
+
+// highlightCmd represents the highlight command
+var highlightCmd = &cobra.Command{
+	Use:   "highlight",
+	Short: "Color the parts of a stream that match",
+	Long: fmt.Sprintf(`Color only the parts of the text that match regular expressions.
+
+	A rule is regex=color where the last = separates the regex from the color. A color is a comma
+	or + separated list of a color and attributes [%s]. A color is a name [%s],
+	#rrggbb, an index of the 256 color palette or R.G.B and with a bg: prefix it is the background.
+	Capture groups get their own color with N:color, multiple colors are separated by ;
+
+	Example 1 : errors in bold white on red, warnings in orange and request ids in cyan
+	sp highlight -e 'ERROR=bg:red+white+bold' -e 'WARN(ING)?=255.128.0' -e 'req=[0-9a-f]+=cyan'
+
+	Example 2 : only color the user name of a match and the match itself in yellow
+	sp highlight -e 'user=(\w+)=yellow;1:cyan,bold'
+
+	Where matches overlap the rule that is given first wins. A capture group color wins over the
+	color of its match. Without --err-expr stderr uses the same rules as stdout.
+	`, strings.Join(c.AttributeNames(), ", "), strings.Join(c.ColorNames(), ", ")),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		if err == nil && force {
+			color.NoColor = false
+		}
+		outRules, err := getHighlightRules(cmd, getOutFlagName(fHighlightExpr))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			return
+		}
+		errRules, err := getHighlightRules(cmd, getErrFlagName(fHighlightExpr))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			return
+		}
+		if !cmd.Flags().Changed(getErrFlagName(fHighlightExpr)) {
+			errRules = outRules
+		}
+		stdoutWriter = getHighlighter(stdout, outRules)
+		stderrWriter = getHighlighter(stderr, errRules)
+	},
+}
+
+const fHighlightExpr = "expr"
+
+func getHighlightRules(cmd *cobra.Command, flagName string) ([]c.HighlightRule, error) {
+	exprs, err := cmd.Flags().GetStringArray(flagName)
+	if err != nil {
+		return nil, err
+	}
+	var rules = make([]c.HighlightRule, 0, len(exprs))
+	for _, expr := range exprs {
+		rule, err := c.ParseHighlightRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func getHighlighter(outputType outputType, rules []c.HighlightRule) io.Writer {
+	if len(rules) == 0 {
+		return getBaseWriter(outputType)
+	}
+	return c.NewHighlighter(getBaseWriter(outputType), rules)
+}
+
+func init() {
+	rootCmd.AddCommand(highlightCmd)
+
+	highlightCmd.Flags().StringArrayP(getOutFlagName(fHighlightExpr), "e", nil, "A regex=color rule for stdout, can be repeated")
+	highlightCmd.Flags().StringArray(getErrFlagName(fHighlightExpr), nil, "A regex=color rule for stderr, can be repeated")
+	highlightCmd.Flags().Bool("force", false, "Whether to force coloring regardless of type of outputstream.")
+}
@@ -0,0 +1,43 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const fErrFd = "err-fd"
+const fErrFile = "err-file"
+const fStdinAsStderr = "stdin-as-stderr"
+
+// getErrInput returns the second input of piped mode which is processed as
+// stderr. It is nil when no such input is configured.
+func getErrInput() (io.Reader, error) {
+	errFd, err := rootCmd.PersistentFlags().GetInt(fErrFd)
+	if err != nil {
+		return nil, err
+	}
+	errFile, err := rootCmd.PersistentFlags().GetString(fErrFile)
+	if err != nil {
+		return nil, err
+	}
+	if errFd >= 0 && errFile != "" {
+		return nil, fmt.Errorf("only one of --%s and --%s can be used", fErrFd, fErrFile)
+	}
+	if errFd >= 0 {
+		return os.NewFile(uintptr(errFd), fmt.Sprintf("fd %d", errFd)), nil
+	}
+	if errFile != "" {
+		return os.Open(errFile)
+	}
+	return nil, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().Int(fErrFd, -1, "In piped mode also read this file descriptor and process it as stderr (e.g. 3 when invoked with 3< <(cmd 2>&1 >/dev/null))")
+	rootCmd.PersistentFlags().String(fErrFile, "", "In piped mode also read this file or named pipe and process it as stderr")
+	rootCmd.PersistentFlags().Bool(fStdinAsStderr, false, "In piped mode process stdin as stderr (e.g. cmd 2> >(sp --stdin-as-stderr color))")
+}
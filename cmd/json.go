@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	jsonwriter "github.com/pvbouwel/sp/json"
+	"github.com/spf13/cobra"
+)
+
+// jsonCmd represents the json command
+var jsonCmd = &cobra.Command{
+	Use:   "json",
+	Short: "Reformat JSON objects",
+	Long: `Reformat the JSON objects and arrays that are part of the input.
+
+	Only the JSON values are rewritten, the text around them is left alone. Values that are
+	not valid JSON are not changed.
+
+	Example 1 : make the payload of a log line readable
+	echo '2025-01-01 INFO payload={"b":1,"a":[1,2]}' | sp json --pretty
+
+	Example 2 : put objects on a single line with their keys sorted
+	sp json --compact --sort-keys -- app
+
+	Without --pretty or --compact objects are pretty printed.
+
+	With --fields objects are turned into a line with the values of the given fields (keys or paths
+	like log.level) in that order. A field with a trailing = is shown as key=value. With --tail the
+	other fields follow as key=value pairs. With --keep-json the result stays a JSON object instead.
+
+	Example 3 : show a short human line with the error labelled and everything else at the end
+	sp json --fields ts,level,msg,err= --tail -- app
+
+	Example 4 : only keep and reorder some fields
+	sp json --fields ts,level,msg --keep-json --compact -- app
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		options, err := getFormatOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error: %s", err)
+			return
+		}
+		stdoutWriter = jsonwriter.NewReformatWriter(getBaseWriter(stdout), options)
+		stderrWriter = jsonwriter.NewReformatWriter(getBaseWriter(stderr), options)
+	},
+}
+
+const fJSONPretty = "pretty"
+const fJSONCompact = "compact"
+const fJSONIndent = "indent"
+const fJSONSortKeys = "sort-keys"
+const fJSONFields = "fields"
+const fJSONTail = "tail"
+const fJSONKeepJSON = "keep-json"
+
+func getFormatOptions(cmd *cobra.Command) (jsonwriter.FormatOptions, error) {
+	var options = jsonwriter.DefaultFormatOptions()
+	pretty, err := cmd.Flags().GetBool(fJSONPretty)
+	if err != nil {
+		return options, err
+	}
+	options.Compact, err = cmd.Flags().GetBool(fJSONCompact)
+	if err != nil {
+		return options, err
+	}
+	if pretty && options.Compact {
+		return options, errors.New("--pretty and --compact cannot be combined")
+	}
+	indent, err := cmd.Flags().GetInt(fJSONIndent)
+	if err != nil {
+		return options, err
+	}
+	if indent < 0 {
+		return options, fmt.Errorf("invalid indent %d", indent)
+	}
+	options.Indent = strings.Repeat(" ", indent)
+	options.SortKeys, err = cmd.Flags().GetBool(fJSONSortKeys)
+	if err != nil {
+		return options, err
+	}
+	fields, err := cmd.Flags().GetString(fJSONFields)
+	if err != nil {
+		return options, err
+	}
+	if fields != "" {
+		options.Fields = strings.Split(fields, ",")
+	}
+	options.Tail, err = cmd.Flags().GetBool(fJSONTail)
+	if err != nil {
+		return options, err
+	}
+	keepJSON, err := cmd.Flags().GetBool(fJSONKeepJSON)
+	if err != nil {
+		return options, err
+	}
+	options.Human = len(options.Fields) > 0 && !keepJSON
+	return options, nil
+}
+
+func init() {
+	rootCmd.AddCommand(jsonCmd)
+
+	jsonCmd.Flags().Bool(fJSONPretty, false, "Pretty print JSON objects over multiple lines")
+	jsonCmd.Flags().Bool(fJSONCompact, false, "Write JSON objects on a single line without extra whitespace")
+	jsonCmd.Flags().Int(fJSONIndent, 2, "Number of spaces to indent a level with when pretty printing")
+	jsonCmd.Flags().Bool(fJSONSortKeys, false, "Sort the keys of JSON objects")
+	jsonCmd.Flags().String(fJSONFields, "", "Comma separated keys or paths of the fields to show in that order, a trailing = shows the key as well")
+	jsonCmd.Flags().Bool(fJSONTail, false, "Show the fields that are not in --fields after them")
+	jsonCmd.Flags().Bool(fJSONKeepJSON, false, "Keep the fields as a JSON object instead of a human readable line")
+}
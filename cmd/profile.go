@@ -0,0 +1,256 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pvbouwel/sp/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const fProfile = "profile"
+const fProfileShort = "p"
+
+// Profiles that are always available, the config file can override them
+func builtinProfiles() []config.Profile {
+	return []config.Profile{
+		{
+			Name:        "rainbow",
+			Description: "Rainbow colours",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--color-type", "rotating", "--rotating-type", "random", "--stride-length", "15-25"}}},
+		},
+		{
+			Name:        "json-traffic-levelname",
+			Description: "Colour JSON depending on values of the field called levelname and have alternating colours if subsequent lines match",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--ignore-case", "--color-type", "JSON", "--json-key", "levelname", "--colors", trafficLightColors}}},
+		},
+		{
+			Name:        "json-traffic-level",
+			Description: "The same for the field called level",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--ignore-case", "--color-type", "JSON", "--json-key", "level", "--colors", trafficLightColors}}},
+		},
+		{
+			Name:        "logfmt-traffic-level",
+			Description: "The same for logfmt",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--ignore-case", "--color-type", "logfmt", "--json-key", "level", "--colors", trafficLightColors}}},
+		},
+		{
+			Name:        "json-loud-errors",
+			Description: "Make errors stand out white on red and bold",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--ignore-case", "--color-type", "JSON", "--json-key", "level", "--colors", "error.bg:red+bright-white+bold"}}},
+		},
+		{
+			Name:        "traffic",
+			Description: "Traffic light colours for plain text logs of which the level is detected",
+			Stages:      []config.Stage{{Command: "color", Args: []string{"--color-type", "level"}}},
+		},
+		{
+			Name:        "stdouterr",
+			Description: "Allow colouring of stoud and stderr differently",
+			Stages:      []config.Stage{{Command: "color"}},
+		},
+		{
+			Name:        "epoch",
+			Description: "Replace epoch occurrences with human readable time",
+			Stages:      []config.Stage{{Command: "epoch"}},
+		},
+	}
+}
+
+// getProfiles returns the built-in profiles followed by those of the config
+// file. A configured profile with the name of a built-in one replaces it.
+func getProfiles() ([]config.Profile, error) {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		return nil, err
+	}
+	profiles := builtinProfiles()
+	for _, p := range cfg.Profiles {
+		idx := slices.IndexFunc(profiles, func(b config.Profile) bool { return b.Name == p.Name })
+		if idx == -1 {
+			profiles = append(profiles, p)
+		} else {
+			profiles[idx] = p
+		}
+	}
+	return profiles, nil
+}
+
+// valueFollows tells whether the argument after arg is the value of the flag
+// arg, e.g. for -e in highlight -e '-pid=red'.
+func valueFollows(cmd *cobra.Command, arg string) bool {
+	var lookup = func(name string, short bool) *pflag.Flag {
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+			if short && flags.ShorthandLookup(name) != nil {
+				return flags.ShorthandLookup(name)
+			}
+			if !short && flags.Lookup(name) != nil {
+				return flags.Lookup(name)
+			}
+		}
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		f := lookup(strings.TrimPrefix(arg, "--"), false)
+		return !strings.Contains(arg, "=") && f != nil && f.NoOptDefVal == ""
+	case strings.HasPrefix(arg, "-") && !strings.Contains(arg, "="):
+		//Shorthands can be combined, only the last one can take the next argument
+		for i := 1; i < len(arg); i++ {
+			f := lookup(arg[i:i+1], true)
+			if f == nil {
+				return false
+			}
+			if f.NoOptDefVal == "" {
+				return i == len(arg)-1
+			}
+		}
+	}
+	return false
+}
+
+// profileArg returns the name of the profile if args[i] selects one and how
+// many arguments that takes.
+func profileArg(args []string, i int) (string, int, error) {
+	arg := args[i]
+	switch {
+	case arg == "-"+fProfileShort || arg == "--"+fProfile:
+		if i+1 == len(args) {
+			return "", 0, fmt.Errorf("%s requires the name of a profile", arg)
+		}
+		return args[i+1], 2, nil
+	case strings.HasPrefix(arg, "--"+fProfile+"="):
+		return strings.TrimPrefix(arg, "--"+fProfile+"="), 1, nil
+	case strings.HasPrefix(arg, "-"+fProfileShort) && !strings.HasPrefix(arg, "--"):
+		return strings.TrimPrefix(arg[2:], "="), 1, nil
+	}
+	return "", 0, nil
+}
+
+// expandProfiles replaces a stage that selects a profile by the stages of the
+// profile. The other arguments of that stage are added to the last stage of
+// the profile such that they take precedence over the flags of the profile.
+// The profiles are only loaded if a stage selects one such that a broken config
+// file does not get in the way of other commands.
+func expandProfiles(stages [][]string, loadProfiles func() ([]config.Profile, error)) ([][]string, error) {
+	var expanded = make([][]string, 0, len(stages))
+	var profiles []config.Profile
+	for _, stage := range stages {
+		var name string
+		var rest = make([]string, 0, len(stage))
+		cmd, _, err := rootCmd.Find(stage)
+		if err != nil {
+			cmd = rootCmd
+		}
+		for i := 0; i < len(stage); i++ {
+			profileName, n, err := profileArg(stage, i)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 && valueFollows(cmd, stage[i]) {
+				rest = append(rest, stage[i:min(i+2, len(stage))]...)
+				i++
+				continue
+			}
+			if n == 0 {
+				rest = append(rest, stage[i])
+				continue
+			}
+			if name != "" {
+				return nil, fmt.Errorf("only one profile can be used per stage got %s and %s", name, profileName)
+			}
+			name = profileName
+			i += n - 1
+		}
+		if name == "" {
+			expanded = append(expanded, stage)
+			continue
+		}
+		if profiles == nil {
+			profiles, err = loadProfiles()
+			if err != nil {
+				return nil, err
+			}
+		}
+		idx := slices.IndexFunc(profiles, func(p config.Profile) bool { return p.Name == name })
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown profile %s, available: %s", name, strings.Join(profileNames(profiles), ", "))
+		}
+		profileStages := profiles[idx].Stages
+		for i, s := range profileStages {
+			args := stageArgs(s)
+			if i == len(profileStages)-1 {
+				args = append(args, rest...)
+			}
+			expanded = append(expanded, args)
+		}
+	}
+	return expanded, nil
+}
+
+func profileNames(profiles []config.Profile) []string {
+	var names = make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// stageArgs returns the command line arguments of a stage. A flag with
+// multiple values is repeated if it can be given multiple times (e.g. -e of
+// highlight) and otherwise gets its values comma separated.
+func stageArgs(s config.Stage) []string {
+	var args = append([]string{s.Command}, s.Args...)
+	var lookup = func(string) *pflag.Flag { return nil }
+	if cmd, _, err := rootCmd.Find([]string{s.Command}); err == nil {
+		lookup = cmd.Flag
+	}
+	for _, flag := range s.Flags {
+		f := lookup(flag.Name)
+		switch {
+		case f != nil && f.Value.Type() == "stringArray":
+			for _, v := range flag.Values {
+				args = append(args, "--"+flag.Name, v)
+			}
+		case f != nil && f.Value.Type() == "bool" && len(flag.Values) == 1:
+			args = append(args, "--"+flag.Name+"="+flag.Values[0])
+		default:
+			args = append(args, "--"+flag.Name, strings.Join(flag.Values, ","))
+		}
+	}
+	return args
+}
+
+// Arguments that need no quoting in a shell
+var shellSafeRegexp = regexp.MustCompile(`^[A-Za-z0-9_./:=,+@%-]+$`)
+
+func shellQuote(arg string) string {
+	if shellSafeRegexp.MatchString(arg) {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(arg) + `"`
+}
+
+// profileCommand returns the sp command line that runs the profile
+func profileCommand(p config.Profile) string {
+	var words = []string{"sp"}
+	for i, s := range p.Stages {
+		if i > 0 {
+			words = append(words, stageSeparator)
+		}
+		for _, arg := range stageArgs(s) {
+			words = append(words, shellQuote(arg))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringP(fProfile, fProfileShort, "", fmt.Sprintf("Use the stages of a named profile from %s (see sp aliases), other flags of the stage are added to its last stage", config.Path()))
+}
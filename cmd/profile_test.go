@@ -0,0 +1,144 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pvbouwel/sp/config"
+)
+
+var testProfiles = []config.Profile{
+	{
+		Name: "jt",
+		Stages: []config.Stage{
+			{Command: "epoch", Args: []string{"--tz", "local"}, Flags: []config.Flag{{Name: "keep-original", Values: []string{"true"}}}},
+			{Command: "color", Flags: []config.Flag{
+				{Name: "color-type", Values: []string{"JSON"}},
+				{Name: "colors", Values: []string{"info.0.255.0", "error.255.0.0"}},
+			}},
+		},
+	},
+	{
+		Name:   "hl",
+		Stages: []config.Stage{{Command: "highlight", Flags: []config.Flag{{Name: "expr", Values: []string{"ERROR=red", "WARN=yellow"}}}}},
+	},
+}
+
+var jtStages = [][]string{
+	{"epoch", "--tz", "local", "--keep-original=true"},
+	{"color", "--color-type", "JSON", "--colors", "info.0.255.0,error.255.0.0"},
+}
+
+func loadTestProfiles() ([]config.Profile, error) {
+	return testProfiles, nil
+}
+
+// Fails the expansion if profiles get loaded
+func loadNoProfiles() ([]config.Profile, error) {
+	return nil, errors.New("profiles were loaded")
+}
+
+func TestExpandProfiles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stages   [][]string
+		load     func() ([]config.Profile, error)
+		expected [][]string
+	}{
+		{"no profile", [][]string{{"epoch", "--tz", "local"}}, loadNoProfiles, [][]string{{"epoch", "--tz", "local"}}},
+		{"short", [][]string{{"-p", "jt"}}, loadTestProfiles, jtStages},
+		{"long", [][]string{{"--profile", "jt"}}, loadTestProfiles, jtStages},
+		{"long with =", [][]string{{"--profile=jt"}}, loadTestProfiles, jtStages},
+		{"short joined", [][]string{{"-pjt"}}, loadTestProfiles, jtStages},
+		{"other flags go to the last stage", [][]string{{"--ignore-case", "-p", "jt", "--force"}}, loadTestProfiles, [][]string{
+			jtStages[0],
+			append(append([]string{}, jtStages[1]...), "--ignore-case", "--force"),
+		}},
+		{"repeated string array flag", [][]string{{"epoch"}, {"-p", "hl"}}, loadTestProfiles, [][]string{
+			{"epoch"},
+			{"highlight", "--expr", "ERROR=red", "--expr", "WARN=yellow"},
+		}},
+		{"flag value starting with -p", [][]string{{"highlight", "-e", "-pid=red", "--expr", "-p"}}, loadNoProfiles, [][]string{
+			{"highlight", "-e", "-pid=red", "--expr", "-p"},
+		}},
+	}
+	for _, tc := range testCases {
+		//WHEN the stages are expanded
+		expanded, err := expandProfiles(tc.stages, tc.load)
+
+		//THEN stages that select a profile are replaced by those of the profile
+		if err != nil {
+			t.Errorf("%s: encountered error: %s", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(expanded, tc.expected) {
+			t.Errorf("%s\nExpected:%q\nGot     :%q", tc.name, tc.expected, expanded)
+		}
+	}
+}
+
+func TestExpandProfilesErrors(t *testing.T) {
+	for _, stages := range [][][]string{
+		{{"-p", "unknown"}},
+		{{"-p", "jt", "--profile", "hl"}},
+		{{"color", "-p"}},
+	} {
+		//WHEN invalid profile selections are expanded THEN they fail
+		_, err := expandProfiles(stages, loadTestProfiles)
+		if err == nil {
+			t.Errorf("Expected an error for %q", stages)
+		}
+	}
+}
+
+func TestValueFollows(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"epoch", "--tz"}, true},
+		{[]string{"epoch", "--tz=local"}, false},
+		{[]string{"epoch", "--keep-original"}, false},
+		{[]string{"highlight", "-e"}, true},
+		{[]string{"highlight", "-pid=red"}, false},
+		{[]string{"highlight", "--unknown"}, false},
+		{[]string{"--max-line-length"}, true},
+	}
+	for _, tc := range testCases {
+		//Given the command of the arguments
+		cmd, _, err := rootCmd.Find(tc.args)
+		if err != nil {
+			t.Errorf("Could not find command of %q: %s", tc.args, err)
+			continue
+		}
+
+		//WHEN checking whether the last argument takes the next one as value
+		got := valueFollows(cmd, tc.args[len(tc.args)-1])
+
+		//THEN only flags that need a value do
+		if got != tc.expected {
+			t.Errorf("%q\nExpected:%t\nGot     :%t", tc.args, tc.expected, got)
+		}
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	//Given a profile with multiple stages and arguments that need quoting
+	profile := config.Profile{Name: "q", Stages: []config.Stage{
+		{Command: "epoch", Args: []string{"--tz", "local"}},
+		{Command: "highlight", Flags: []config.Flag{{Name: "expr", Values: []string{`req=\w+=cyan`, "a b=red"}}}},
+	}}
+
+	//WHEN its command line is made
+	got := profileCommand(profile)
+
+	//THEN the stages are separated and arguments quoted for a shell
+	expected := `sp epoch --tz local , highlight --expr "req=\\w+=cyan" --expr "a b=red"`
+	if got != expected {
+		t.Errorf("\nExpected:%s\nGot     :%s", expected, got)
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"io"
 	"os"
 	"slices"
+	"time"
 
 	"github.com/pvbouwel/sp/streams"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var appName string
@@ -18,9 +20,18 @@ var appArgs []string
 
 const appSeparator string = "--"
 
+// stageSeparator splits the sp arguments into multiple stages which are chained
+// in a single process. Data flows from the first stage to the last one.
+const stageSeparator string = ","
+
 var stdoutWriter io.Writer
 var stderrWriter io.Writer
 
+// The writers of the stage that comes after the one being initialized. A stage
+// must wrap these so its output ends up in the rest of the pipeline.
+var downstreamStdoutWriter io.Writer = os.Stdout
+var downstreamStderrWriter io.Writer = os.Stderr
+
 func isAppSepartor(s string) bool {
 	return s == appSeparator
 }
@@ -42,6 +53,42 @@ func proccessAppArguments() {
 	}
 }
 
+func isStageSeparator(s string) bool {
+	return s == stageSeparator
+}
+
+// splitStages splits arguments on stageSeparator. There is always at least one
+// stage even if it has no arguments.
+func splitStages(args []string) [][]string {
+	var stages = make([][]string, 0)
+	for {
+		idx := slices.IndexFunc(args, isStageSeparator)
+		if idx == -1 {
+			return append(stages, args)
+		}
+		stages = append(stages, args[0:idx])
+		args = args[idx+1:]
+	}
+}
+
+// resetFlags puts the flags of cmd and its subcommands back to their defaults
+// such that a subcommand used in multiple stages does not inherit flag values
+// from another stage.
+func resetFlags(cmd *cobra.Command) {
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok && f.DefValue == "[]" {
+			//Setting would add "[]" as an element
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	for _, subCmd := range cmd.Commands() {
+		resetFlags(subCmd)
+	}
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "sp",
@@ -50,38 +97,94 @@ var rootCmd = &cobra.Command{
 
 The default way to use it is to pipe it onto a command in order to process its stdout.
 If you want to process both stdout and stderr and keep them appart you specify the sp
-command followed by -- followed by the command you want to run. Potentially chained. For example:
-	sp color --force %s sp epoch %s ./your_scripts/print_epochs.sh
+command followed by -- followed by the command you want to run.
 
-Would color stderr and stdout differently (see color subcommand for defaults and it will also replace epochs).
-`, appSeparator, appSeparator),
+Multiple processing steps can be chained in a single sp invocation by separating them with %s.
+The output of a step is the input of the next one. For example:
+	sp epoch %s color --force %s ./your_scripts/print_epochs.sh
+
+Would replace epochs and then color stderr and stdout differently (see color subcommand for defaults).
+`, stageSeparator, stageSeparator, appSeparator),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	proccessAppArguments()
-	err := rootCmd.Execute()
+	stages, err := expandProfiles(splitStages(os.Args[1:]), getProfiles)
 	if err != nil {
-		fmt.Fprint(os.Stderr, "Encountered issues processing sp initialization")
+		fmt.Fprintf(os.Stderr, "Encountered issues processing sp initialization: %s", err)
 		os.Exit(1)
 	}
-	if appName == "" {
+	// The last stage writes to the actual outputs so it is initialized first,
+	// every earlier stage then wraps the writers of the stage after it.
+	for i := len(stages) - 1; i >= 0; i-- {
+		stdoutWriter = nil
+		stderrWriter = nil
+		resetFlags(rootCmd)
+		rootCmd.SetArgs(stages[i])
+		err := rootCmd.Execute()
+		if err != nil {
+			fmt.Fprint(os.Stderr, "Encountered issues processing sp initialization")
+			os.Exit(1)
+		}
 		if stdoutWriter == nil {
 			fmt.Fprint(os.Stderr, "After sp initialization stdout writer was still nil")
 			os.Exit(1)
 		}
-		os.Exit(streams.NewPipedApp(stdoutWriter).Run())
-	} else {
+		stdoutWriter = newStageWriter(stdoutWriter, downstreamStdoutWriter)
 		if stderrWriter == nil {
-			stderrWriter = os.Stderr
+			stderrWriter = downstreamStderrWriter
+		} else {
+			stderrWriter = newStageWriter(stderrWriter, downstreamStderrWriter)
 		}
-		if stdoutWriter == nil {
-			fmt.Fprint(os.Stderr, "After sp initialization stdout writer was still nil")
-			os.Exit(1)
+		downstreamStdoutWriter = stdoutWriter
+		downstreamStderrWriter = stderrWriter
+	}
+	app, err := getApp()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered issues processing sp initialization: %s", err)
+		os.Exit(1)
+	}
+	os.Exit(app.Run())
+}
+
+// getApp returns the app that feeds the input into the writers of the stages.
+func getApp() (streams.App, error) {
+	maxLineLength, err := rootCmd.PersistentFlags().GetInt(fMaxLineLength)
+	if err != nil {
+		return nil, err
+	}
+	if appName != "" {
+		lineFlushTimeout, err := rootCmd.PersistentFlags().GetDuration(fLineFlushTimeout)
+		if err != nil {
+			return nil, err
 		}
-		os.Exit(streams.NewSpawnedApp(NewSyncedWriter(stdoutWriter), NewSyncedWriter(stderrWriter), appName, appArgs).Run())
+		return streams.NewSpawnedApp(NewSyncedWriter(stdoutWriter), NewSyncedWriter(stderrWriter), appName, appArgs, lineFlushTimeout, maxLineLength), nil
 	}
+
+	stdinAsStderr, err := rootCmd.PersistentFlags().GetBool(fStdinAsStderr)
+	if err != nil {
+		return nil, err
+	}
+	errInput, err := getErrInput()
+	if err != nil {
+		return nil, err
+	}
+	var stdinWriter = stdoutWriter
+	if stdinAsStderr {
+		stdinWriter = stderrWriter
+	}
+	if errInput == nil {
+		return streams.NewPipedApp(stdinWriter, nil, nil, maxLineLength), nil
+	}
+	return streams.NewPipedApp(NewSyncedWriter(stdinWriter), errInput, NewSyncedWriter(stderrWriter), maxLineLength), nil
 }
 
-func init() {}
+const fLineFlushTimeout = "line-flush-timeout"
+const fMaxLineLength = "max-line-length"
+
+func init() {
+	rootCmd.PersistentFlags().Duration(fLineFlushTimeout, 100*time.Millisecond, "How long to wait for the end of a partial line of a spawned app before processing it anyway")
+	rootCmd.PersistentFlags().Int(fMaxLineLength, 0, "Truncate lines longer than this number of bytes (0 means no limit)")
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStages(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected [][]string
+	}{
+		{[]string{}, [][]string{{}}},
+		{[]string{"epoch", "--tz", "local"}, [][]string{{"epoch", "--tz", "local"}}},
+		{[]string{"epoch", ",", "highlight", "-e", "a,b=red", ",", "color"}, [][]string{{"epoch"}, {"highlight", "-e", "a,b=red"}, {"color"}}},
+	}
+	for _, tc := range testCases {
+		//WHEN arguments are split into stages
+		got := splitStages(tc.args)
+
+		//THEN only a lone separator splits
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("\nExpected:%q\nGot     :%q", tc.expected, got)
+		}
+	}
+}
+
+func TestResetFlags(t *testing.T) {
+	//Given a stage which sets flags including a repeated array flag
+	var stages = [][]string{
+		{"highlight", "-e", "a=red", "--expr", "b=red", "--err-expr", "c=red", "--force"},
+		{"epoch", "--tz", "local", "--keep-original"},
+	}
+	for _, stage := range stages {
+		rootCmd.SetArgs(stage)
+		err := rootCmd.Execute()
+		if err != nil {
+			t.Errorf("Could not execute %q: %s", stage, err)
+		}
+	}
+
+	//WHEN the flags are reset and the subcommands are used in another stage
+	resetFlags(rootCmd)
+	rootCmd.SetArgs([]string{"highlight", "-e", "d=red"})
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Errorf("Could not execute highlight: %s", err)
+	}
+
+	//THEN they do not inherit the values of the earlier stage
+	exprs, err := highlightCmd.Flags().GetStringArray(fHighlightExpr)
+	if err != nil || !reflect.DeepEqual(exprs, []string{"d=red"}) {
+		t.Errorf("\nExpected:%q\nGot     :%q (error %v)", []string{"d=red"}, exprs, err)
+	}
+	errExprs, err := highlightCmd.Flags().GetStringArray(getErrFlagName(fHighlightExpr))
+	if err != nil || len(errExprs) != 0 {
+		t.Errorf("\nExpected:%q\nGot     :%q (error %v)", []string{}, errExprs, err)
+	}
+	force := highlightCmd.Flags().Lookup("force")
+	if force.Value.String() != "false" || force.Changed {
+		t.Errorf("\nExpected:false\nGot     :%s (changed %t)", force.Value, force.Changed)
+	}
+	for _, name := range []string{fEpochTimeZone, fEpochKeepOriginal} {
+		f := epochCmd.Flags().Lookup(name)
+		if f.Value.String() != f.DefValue || f.Changed {
+			t.Errorf("%s\nExpected:%s\nGot     :%s (changed %t)", name, f.DefValue, f.Value, f.Changed)
+		}
+	}
+}
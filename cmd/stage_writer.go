@@ -0,0 +1,36 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package cmd
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pvbouwel/sp/streams"
+)
+
+// stageWriter is the writer of a stage together with the writer of the stage
+// after it. Writers only know what they wrap as an io.Writer so this passes a
+// Flush at the end of the input on through the whole pipeline, stage by stage.
+type stageWriter struct {
+	io.Writer
+	downstream io.Writer
+}
+
+func newStageWriter(w io.Writer, downstream io.Writer) io.Writer {
+	if w == downstream {
+		//The stage does not process this output
+		return w
+	}
+	return &stageWriter{
+		Writer:     w,
+		downstream: downstream,
+	}
+}
+
+func (s *stageWriter) Flush() error {
+	//What the stage held back goes downstream first
+	err := streams.Flush(s.Writer)
+	return errors.Join(err, streams.Flush(s.downstream))
+}
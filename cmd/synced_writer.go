@@ -6,6 +6,8 @@ package cmd
 import (
 	"io"
 	"sync"
+
+	"github.com/pvbouwel/sp/streams"
 )
 
 var syncedWriterMutex *sync.Mutex = &sync.Mutex{}
@@ -28,3 +30,9 @@ func (s *syncedWriter) Write(p []byte) (int, error) {
 	defer syncedWriterMutex.Unlock()
 	return s.w.Write(p)
 }
+
+func (s *syncedWriter) Flush() error {
+	syncedWriterMutex.Lock()
+	defer syncedWriterMutex.Unlock()
+	return streams.Flush(s.w)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color
+
+import (
+	"hash/fnv"
+	"io"
+	"regexp"
+
+	"github.com/fatih/color"
+)
+
+// HashColor picks a colour from the palette based on a hash of value. The
+// same value always gets the same colour.
+func HashColor(palette []*color.Color, value []byte) *color.Color {
+	if len(palette) == 0 {
+		return nil
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(value)
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+type hashColor struct {
+	wrapped io.Writer
+
+	palette []*color.Color
+
+	//Decides what is hashed, the first capture group or the whole match if it
+	//has no groups. nil means the whole write is hashed.
+	re *regexp.Regexp
+}
+
+func NewHashColor(w io.Writer, palette []*color.Color, re *regexp.Regexp) io.Writer {
+	return &hashColor{
+		wrapped: w,
+		palette: palette,
+		re:      re,
+	}
+}
+
+func (hc *hashColor) Write(p []byte) (n int, err error) {
+	var value = p
+	if hc.re != nil {
+		match := hc.re.FindSubmatch(p)
+		if match == nil {
+			return hc.wrapped.Write(p)
+		}
+		value = match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+	}
+	if len(value) == 0 {
+		return hc.wrapped.Write(p)
+	}
+	c := HashColor(hc.palette, value)
+	c.SetWriter(hc.wrapped)
+	n, err = hc.wrapped.Write(p)
+	c.UnsetWriter(hc.wrapped)
+	return n, err
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+)
+
+func TestHashColorCaptureGroup(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a buffer to write into
+	rb := new(bytes.Buffer)
+
+	palette := []*color.Color{color.New(color.FgRed), color.New(color.FgGreen), color.New(color.FgBlue)}
+	//WHEN we hash the request id of lines
+	w := c.NewHashColor(rb, palette, regexp.MustCompile(`req=([0-9a-f]+)`))
+	for _, line := range []string{"a req=1f", "b req=1f", "no id"} {
+		_, err := w.Write([]byte(line))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+	}
+
+	//THEN lines with the same id share a colour and others are left alone
+	expectedColor := c.HashColor(palette, []byte("1f"))
+	expected := expectedColor.Sprint("a req=1f") + expectedColor.Sprint("b req=1f") + "no id"
+	if rb.String() != expected {
+		t.Errorf("\nExpected:%q\nGot     :%q", expected, rb.String())
+	}
+}
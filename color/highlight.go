@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// HighlightRule colours the matches of a regular expression
+type HighlightRule struct {
+	Regexp *regexp.Regexp
+
+	//Colour of the whole match, nil to only colour capture groups
+	Color *color.Color
+
+	//Colours of capture groups by group number, they take precedence over
+	//Color for the part of the match they cover
+	GroupColors map[int]*color.Color
+}
+
+// ParseHighlightRule parses regex=spec where the last = separates the regex
+// from the spec. The spec is a ; separated list of colour specs (see
+// ParseSpec) for the whole match or, when prefixed by N:, for capture group N
+// (e.g. 'user=(\w+)=yellow;1:cyan,bold').
+func ParseHighlightRule(expr string) (HighlightRule, error) {
+	var rule HighlightRule
+	idx := strings.LastIndexByte(expr, '=')
+	if idx <= 0 {
+		return rule, fmt.Errorf("invalid highlight rule %s expected regex=color", expr)
+	}
+	var err error
+	rule.Regexp, err = regexp.Compile(expr[0:idx])
+	if err != nil {
+		return rule, fmt.Errorf("invalid regex in highlight rule %s: %s", expr, err)
+	}
+	for _, spec := range strings.Split(expr[idx+1:], ";") {
+		group := 0
+		//A prefix like 1: selects a capture group, other prefixes like bg: are
+		//part of the color spec
+		if before, after, found := strings.Cut(spec, ":"); found && isDigits(before) {
+			group, err = strconv.Atoi(before)
+			if err != nil || group < 0 || group > rule.Regexp.NumSubexp() {
+				return rule, fmt.Errorf("invalid capture group %s in highlight rule %s", before, expr)
+			}
+			spec = after
+		}
+		c, err := ParseSpec(spec)
+		if err != nil {
+			return rule, err
+		}
+		if group == 0 {
+			rule.Color = c
+			continue
+		}
+		if rule.GroupColors == nil {
+			rule.GroupColors = make(map[int]*color.Color)
+		}
+		rule.GroupColors[group] = c
+	}
+	return rule, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type highlighter struct {
+	wrapped io.Writer
+
+	//Rules in order of precedence
+	rules []HighlightRule
+}
+
+// NewHighlighter colours the matches of the rules and leaves the rest of the
+// text alone. Where matches overlap the rule that comes first wins, later
+// rules only colour what is still uncoloured.
+func NewHighlighter(w io.Writer, rules []HighlightRule) io.Writer {
+	return &highlighter{
+		wrapped: w,
+		rules:   rules,
+	}
+}
+
+func (h *highlighter) Write(p []byte) (n int, err error) {
+	//The colour of every byte, nil for uncoloured bytes
+	var colors = make([]*color.Color, len(p))
+	var painted = make([]bool, len(p))
+	var rulePainted = make([]bool, len(p))
+	for _, rule := range h.rules {
+		clear(rulePainted)
+		for _, match := range rule.Regexp.FindAllSubmatchIndex(p, -1) {
+			//Groups first such that they take precedence over the whole match
+			for group := 1; group < len(match)/2; group++ {
+				if c, ok := rule.GroupColors[group]; ok && match[2*group] >= 0 {
+					paint(colors, painted, rulePainted, match[2*group], match[2*group+1], c)
+				}
+			}
+			if rule.Color != nil {
+				paint(colors, painted, rulePainted, match[0], match[1], rule.Color)
+			}
+		}
+		for i, done := range rulePainted {
+			painted[i] = painted[i] || done
+		}
+	}
+
+	var start int
+	for i := 1; i <= len(p); i++ {
+		if i < len(p) && colors[i] == colors[start] {
+			continue
+		}
+		if colors[start] == nil {
+			_, err = h.wrapped.Write(p[start:i])
+		} else {
+			colors[start].SetWriter(h.wrapped)
+			_, err = h.wrapped.Write(p[start:i])
+			colors[start].UnsetWriter(h.wrapped)
+		}
+		if err != nil {
+			return start, err
+		}
+		start = i
+	}
+	return len(p), nil
+}
+
+// paint colours the bytes from start to end that were not painted by an
+// earlier rule or earlier by the current rule.
+func paint(colors []*color.Color, painted []bool, rulePainted []bool, start int, end int, c *color.Color) {
+	for i := start; i < end; i++ {
+		if !painted[i] && !rulePainted[i] {
+			colors[i] = c
+			rulePainted[i] = true
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+)
+
+func TestHighlighter(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given rules of which some overlap
+	var rules = make([]c.HighlightRule, 0)
+	for _, expr := range []string{"ERROR=bg:red+white+bold", "req=[0-9a-f]+=0.255.255", "user=(\\w+)=yellow;1:cyan", "R=green"} {
+		rule, err := c.ParseHighlightRule(expr)
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", expr, err)
+			t.FailNow()
+		}
+		rules = append(rules, rule)
+	}
+
+	//WHEN we write a line with matches
+	rb := new(bytes.Buffer)
+	w := c.NewHighlighter(rb, rules)
+	_, err := w.Write([]byte("ERROR req=ab user=bob RX"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN only the matches are colored, earlier rules win and capture groups win over their match
+	expectedLine := "\x1b[41;37;1mERROR\x1b[0m \x1b[38;2;0;255;255mreq=ab\x1b[0m \x1b[33muser=\x1b[0m\x1b[36mbob\x1b[0m \x1b[32mR\x1b[0mX"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%q\nGot     :%q", expectedLine, rb.String())
+	}
+
+	//WHEN invalid rules are parsed THEN they fail
+	for _, expr := range []string{"ERROR", "(=red", "x=purple", "(a)=2:red", "(a)=1:bg:purple", "a=fg:red"} {
+		_, err = c.ParseHighlightRule(expr)
+		if err == nil {
+			t.Errorf("Expected an error for %s", expr)
+		}
+	}
+}
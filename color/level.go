@@ -0,0 +1,170 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Level is the severity of a log line
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+// Words (lower case) that name a level, including klog's single letters
+var levelWords = map[string]Level{
+	"trace":       LevelTrace,
+	"debug":       LevelDebug,
+	"dbg":         LevelDebug,
+	"d":           LevelDebug,
+	"info":        LevelInfo,
+	"information": LevelInfo,
+	"notice":      LevelInfo,
+	"i":           LevelInfo,
+	"warn":        LevelWarning,
+	"warning":     LevelWarning,
+	"w":           LevelWarning,
+	"error":       LevelError,
+	"err":         LevelError,
+	"e":           LevelError,
+	"fatal":       LevelFatal,
+	"critical":    LevelFatal,
+	"crit":        LevelFatal,
+	"panic":       LevelFatal,
+	"emerg":       LevelFatal,
+	"alert":       LevelFatal,
+	"f":           LevelFatal,
+}
+
+// ParseLevel returns the level named by word (e.g. WARN, warning or W)
+func ParseLevel(word string) (Level, bool) {
+	level, ok := levelWords[strings.ToLower(word)]
+	return level, ok
+}
+
+// LevelHeuristic detects the level of a line
+type LevelHeuristic interface {
+	Detect(line []byte) (Level, bool)
+}
+
+// RegexpLevelHeuristic detects the level by the first capture group of a
+// regex, the group must be a word that names a level.
+type RegexpLevelHeuristic struct {
+	Regexp *regexp.Regexp
+}
+
+func (h RegexpLevelHeuristic) Detect(line []byte) (Level, bool) {
+	match := h.Regexp.FindSubmatch(line)
+	if len(match) < 2 {
+		return LevelUnknown, false
+	}
+	return ParseLevel(string(match[1]))
+}
+
+const levelAlternatives = `TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|ERR|FATAL|CRITICAL|CRIT|PANIC`
+
+var levelHeuristics = map[string]LevelHeuristic{}
+
+// Built-in heuristics in the order they are tried by default
+var defaultLevelHeuristics = []string{"klog", "python", "logfmt", "bracket", "word"}
+
+func init() {
+	//klog header like E0101 12:00:00.000000
+	RegisterLevelHeuristic("klog", RegexpLevelHeuristic{regexp.MustCompile(`^([IWEF])[0-9]{4} [0-9]{2}:`)})
+	//Python logging default format like WARNING:root:message
+	RegisterLevelHeuristic("python", RegexpLevelHeuristic{regexp.MustCompile(`^(DEBUG|INFO|WARNING|ERROR|CRITICAL):`)})
+	//logfmt like level=warn or lvl="error"
+	RegisterLevelHeuristic("logfmt", RegexpLevelHeuristic{regexp.MustCompile(`(?i)\b(?:level|lvl|severity)="?(\w+)`)})
+	//A level between brackets like [ERROR] or [warn]
+	RegisterLevelHeuristic("bracket", RegexpLevelHeuristic{regexp.MustCompile(`(?i)\[(` + levelAlternatives + `)\]`)})
+	//An upper case level word like 2025-01-01 12:00:00 ERROR message
+	RegisterLevelHeuristic("word", RegexpLevelHeuristic{regexp.MustCompile(`\b(` + levelAlternatives + `)\b`)})
+}
+
+// RegisterLevelHeuristic makes a heuristic available by name. Registering a
+// name again replaces the heuristic.
+func RegisterLevelHeuristic(name string, h LevelHeuristic) {
+	levelHeuristics[name] = h
+}
+
+// GetLevelHeuristic returns the registered heuristic with the given name
+func GetLevelHeuristic(name string) (LevelHeuristic, error) {
+	h, ok := levelHeuristics[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown level heuristic %s, available: %s", name, strings.Join(LevelHeuristics(), ", "))
+	}
+	return h, nil
+}
+
+// LevelHeuristics returns the names of the registered heuristics sorted
+func LevelHeuristics() []string {
+	var names = make([]string, 0, len(levelHeuristics))
+	for name := range levelHeuristics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultLevelHeuristics returns the names of the built-in heuristics in the
+// order they are tried
+func DefaultLevelHeuristics() []string {
+	return append([]string{}, defaultLevelHeuristics...)
+}
+
+type levelColor struct {
+	wrapped io.Writer
+
+	//Tried in order, the first one that detects a level decides
+	heuristics []LevelHeuristic
+
+	//Colours of a level, subsequent lines of a level rotate over them
+	palette map[Level][]*color.Color
+
+	i int
+}
+
+// NewLevelColor colours lines based on their detected level. Lines without a
+// level or without colours for their level are written as is.
+func NewLevelColor(w io.Writer, heuristics []LevelHeuristic, palette map[Level][]*color.Color) io.Writer {
+	return &levelColor{
+		wrapped:    w,
+		heuristics: heuristics,
+		palette:    palette,
+	}
+}
+
+func (lc *levelColor) Write(p []byte) (n int, err error) {
+	for _, h := range lc.heuristics {
+		level, ok := h.Detect(p)
+		if !ok {
+			continue
+		}
+		colors := lc.palette[level]
+		if len(colors) == 0 {
+			break
+		}
+		c := colors[lc.i%len(colors)]
+		lc.i = (lc.i + 1) % 100000
+		c.SetWriter(lc.wrapped)
+		n, err = lc.wrapped.Write(p)
+		c.UnsetWriter(lc.wrapped)
+		return n, err
+	}
+	return lc.wrapped.Write(p)
+}
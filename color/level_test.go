@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+)
+
+func TestLevelHeuristics(t *testing.T) {
+	var tests = []struct {
+		line     string
+		expected c.Level
+	}{
+		{"2025-01-01 12:00:00 [ERROR] disk full", c.LevelError},
+		{"2025-01-01 12:00:00 [warn] disk almost full", c.LevelWarning},
+		{"WARNING:root:disk almost full", c.LevelWarning},
+		{"ts=2025-01-01 level=debug msg=\"disk ok\"", c.LevelDebug},
+		{"E0101 12:00:00.000000 1 main.go:1] disk full", c.LevelError},
+		{"2025-01-01 12:00:00 INFO disk ok", c.LevelInfo},
+		{"information about an error", c.LevelUnknown},
+	}
+	var heuristics = make([]c.LevelHeuristic, 0)
+	for _, name := range c.DefaultLevelHeuristics() {
+		h, err := c.GetLevelHeuristic(name)
+		if err != nil {
+			t.Errorf("Default heuristic %s is not registered: %s", name, err)
+			t.FailNow()
+		}
+		heuristics = append(heuristics, h)
+	}
+	for _, tc := range tests {
+		//WHEN the level of a line is detected
+		var level = c.LevelUnknown
+		for _, h := range heuristics {
+			if detected, ok := h.Detect([]byte(tc.line)); ok {
+				level = detected
+				break
+			}
+		}
+
+		//THEN it is the expected one
+		if level != tc.expected {
+			t.Errorf("%s\nExpected:%d\nGot     :%d", tc.line, tc.expected, level)
+		}
+	}
+}
+
+func TestLevelColor(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a level colorer with alternating colors for errors
+	h, err := c.GetLevelHeuristic("bracket")
+	if err != nil {
+		t.Errorf("Could not get heuristic: %s", err)
+		t.FailNow()
+	}
+	rb := new(bytes.Buffer)
+	w := c.NewLevelColor(rb, []c.LevelHeuristic{h}, map[c.Level][]*color.Color{
+		c.LevelError: {color.New(color.FgRed), color.New(color.FgMagenta)},
+	})
+
+	//WHEN lines are written
+	for _, line := range []string{"[ERROR] a", "[INFO] b", "[ERROR] c"} {
+		_, err = w.Write([]byte(line))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+	}
+
+	//THEN lines with a level that has colors are colored and subsequent ones alternate
+	expectedLine := "\x1b[31m[ERROR] a\x1b[0m[INFO] b\x1b[35m[ERROR] c\x1b[0m"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%q\nGot     :%q", expectedLine, rb.String())
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Foreground colours by name, the background is 10 higher
+var namedColors = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"bright-black":   color.FgHiBlack,
+	"gray":           color.FgHiBlack,
+	"grey":           color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+}
+
+// Text attributes by name
+var namedAttributes = map[string]color.Attribute{
+	"bold":          color.Bold,
+	"dim":           color.Faint,
+	"italic":        color.Italic,
+	"underline":     color.Underline,
+	"blink":         color.BlinkSlow,
+	"reverse":       color.ReverseVideo,
+	"strikethrough": color.CrossedOut,
+}
+
+// Prefix of a colour that is used for the background
+const backgroundPrefix = "bg:"
+
+// Distance between a foreground colour and the same background colour
+const backgroundOffset = color.BgBlack - color.FgBlack
+
+func sortedKeys(m map[string]color.Attribute) []string {
+	var names = make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ColorNames returns the sorted names of the colours that can be used in a
+// colour spec
+func ColorNames() []string {
+	return sortedKeys(namedColors)
+}
+
+// AttributeNames returns the sorted names of the attributes that can be used
+// in a colour spec
+func AttributeNames() []string {
+	return sortedKeys(namedAttributes)
+}
+
+// ParseSpec parses a colour spec. That is a list of parts combined with + (or
+// a comma) where a part is an attribute (e.g. bold) or a colour which is one
+// of a name (e.g. red or bright-red), #rrggbb, #rgb, an index of the 256
+// colour palette (e.g. 208) or R.G.B (e.g. 255.128.0). A colour prefixed by
+// bg: is used for the background (e.g. bg:red+white+bold).
+func ParseSpec(spec string) (*color.Color, error) {
+	parts := strings.FieldsFunc(spec, func(r rune) bool { return r == '+' || r == ',' })
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty color spec")
+	}
+	var c = color.New()
+	for _, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if attribute, ok := namedAttributes[part]; ok {
+			c.Add(attribute)
+			continue
+		}
+		value, background := strings.CutPrefix(part, backgroundPrefix)
+		err := addColor(c, value, background)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color %s in %s: %s", part, spec, err)
+		}
+	}
+	return c, nil
+}
+
+func addColor(c *color.Color, value string, background bool) error {
+	if attribute, ok := namedColors[value]; ok {
+		if background {
+			attribute += backgroundOffset
+		}
+		c.Add(attribute)
+		return nil
+	}
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return fmt.Errorf("expected #rrggbb or #rgb")
+		}
+		addRGB(c, int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff), background)
+		return nil
+	}
+	values := strings.Split(value, ".")
+	var numbers [3]int
+	for i, v := range values {
+		number, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || i >= len(numbers) {
+			return fmt.Errorf("expected a name [%s], #rrggbb, a 256 color index, R.G.B or an attribute [%s]",
+				strings.Join(ColorNames(), ", "), strings.Join(AttributeNames(), ", "))
+		}
+		numbers[i] = int(number)
+	}
+	if len(values) == 2 {
+		return fmt.Errorf("expected R.G.B")
+	}
+	if len(values) == 1 {
+		//Index of the 256 color palette
+		if background {
+			c.Add(48, 5, color.Attribute(numbers[0]))
+		} else {
+			c.Add(38, 5, color.Attribute(numbers[0]))
+		}
+		return nil
+	}
+	addRGB(c, numbers[0], numbers[1], numbers[2], background)
+	return nil
+}
+
+func addRGB(c *color.Color, r, g, b int, background bool) {
+	if background {
+		c.AddBgRGB(r, g, b)
+	} else {
+		c.AddRGB(r, g, b)
+	}
+}
+
+// ParseRuleSpec splits rule.spec into the rule and the colour (e.g.
+// error.bg:red+bold or level=info.0.255.0). The colour starts at the first dot
+// after which the rest is a valid colour spec such that R.G.B is preferred and
+// rules can contain dots. The rule is empty when the whole entry is a colour.
+func ParseRuleSpec(entry string) (string, *color.Color, error) {
+	if c, err := ParseSpec(entry); err == nil {
+		return "", c, nil
+	}
+	for i := 0; i < len(entry); i++ {
+		if entry[i] != '.' {
+			continue
+		}
+		if c, err := ParseSpec(entry[i+1:]); err == nil {
+			return entry[0:i], c, nil
+		}
+	}
+	_, err := ParseSpec(entry[strings.LastIndexByte(entry, '.')+1:])
+	return "", nil, fmt.Errorf("invalid color string %s expected rule.color or color: %s", entry, err)
+}
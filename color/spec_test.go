@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package color_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+)
+
+func TestParseSpec(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	var tests = []struct {
+		spec     string
+		expected string
+	}{
+		{"red", "\x1b[31m"},
+		{"bright-red", "\x1b[91m"},
+		{"#ff8800", "\x1b[38;2;255;136;0m"},
+		{"#f80", "\x1b[38;2;255;136;0m"},
+		{"208", "\x1b[38;5;208m"},
+		{"255.128.0", "\x1b[38;2;255;128;0m"},
+		{"bg:red+bright-white+bold", "\x1b[41;97;1m"},
+		{"bg:208,dim,reverse", "\x1b[48;5;208;2;7m"},
+		{"bg:#0000ff+underline+italic", "\x1b[48;2;0;0;255;4;3m"},
+	}
+	for _, tc := range tests {
+		//WHEN a spec is parsed
+		clr, err := c.ParseSpec(tc.spec)
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", tc.spec, err)
+			continue
+		}
+
+		//THEN it sets the expected SGR parameters
+		rb := new(bytes.Buffer)
+		clr.SetWriter(rb)
+		if rb.String() != tc.expected {
+			t.Errorf("%s\nExpected:%q\nGot     :%q", tc.spec, tc.expected, rb.String())
+		}
+	}
+
+	//WHEN invalid specs are parsed THEN they fail
+	for _, spec := range []string{"", "purple", "256", "#ff880", "1.2", "1.2.3.4", "bg:bold"} {
+		_, err := c.ParseSpec(spec)
+		if err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+}
+
+func TestParseRuleSpec(t *testing.T) {
+	var tests = []struct {
+		entry    string
+		expected string
+	}{
+		{"0.255.0", ""},
+		{"info.0.255.0", "info"},
+		{">=500.255.0.0", ">=500"},
+		{"latency>1.5.208", "latency>1.5"},
+		{"error.bg:red+white+bold", "error"},
+		{"log.level=warn.#ff8800", "log.level=warn"},
+	}
+	for _, tc := range tests {
+		//WHEN an entry of --colors is parsed
+		rule, _, err := c.ParseRuleSpec(tc.entry)
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", tc.entry, err)
+			continue
+		}
+
+		//THEN the rule is split from the color
+		if rule != tc.expected {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.entry, tc.expected, rule)
+		}
+	}
+
+	//WHEN there is no valid color THEN it fails
+	_, _, err := c.ParseRuleSpec("info.purple")
+	if err == nil {
+		t.Errorf("Expected an error for info.purple")
+	}
+}
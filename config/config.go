@@ -0,0 +1,270 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfig is the environment variable that overrides the path of the config file
+const EnvConfig = "SP_CONFIG"
+
+// Flag is a flag of a stage, a flag with multiple values is a list
+type Flag struct {
+	Name   string
+	Values []string
+}
+
+// Stage is a processing step of a pipeline
+type Stage struct {
+	//The sp subcommand (e.g. color)
+	Command string
+
+	//Arguments given as they would be on the command line
+	Args []string
+
+	//Flags given by name, they come after Args
+	Flags []Flag
+}
+
+// Profile is a named pipeline that can be used with sp -p name
+type Profile struct {
+	Name        string  `yaml:"-"`
+	Description string  `yaml:"description"`
+	Stages      []Stage `yaml:"stages"`
+}
+
+type Config struct {
+	Profiles []Profile
+}
+
+// Path returns the path of the config file which is $SP_CONFIG or
+// config.yaml in the sp directory of the user's config directory
+// ($XDG_CONFIG_HOME or ~/.config).
+func Path() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "sp", "config.yaml")
+}
+
+// Load reads the config file at path. A file that does not exist is an empty
+// config.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return &Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", path, err)
+	}
+	return c, nil
+}
+
+// Parse parses a config like:
+//
+//	profiles:
+//	  json-traffic:
+//	    description: Colour JSON by its level
+//	    stages:
+//	      - epoch --tz local
+//	      - command: color
+//	        flags:
+//	          color-type: JSON
+//	          colors: [info.0.255.0, error.255.0.0]
+//
+// A stage is either a command line or a command with its flags.
+func Parse(data string) (*Config, error) {
+	var doc struct {
+		Profiles orderedProfiles `yaml:"profiles"`
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&doc)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &Config{Profiles: doc.Profiles}, nil
+}
+
+// orderedProfiles decodes a mapping of names to profiles keeping its order
+type orderedProfiles []Profile
+
+func (o *orderedProfiles) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: profiles must be a mapping of names to profiles", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var p = Profile{Name: n.Content[i].Value}
+		err := checkKeys(n.Content[i+1], "description", "stages")
+		if err == nil {
+			err = n.Content[i+1].Decode(&p)
+		}
+		if err != nil {
+			return err
+		}
+		if len(p.Stages) == 0 {
+			return fmt.Errorf("line %d: profile %s has no stages", n.Content[i].Line, p.Name)
+		}
+		//Block scalars (> or |) end with a newline
+		p.Description = strings.TrimSpace(p.Description)
+		*o = append(*o, p)
+	}
+	return nil
+}
+
+func (s *Stage) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		args, err := SplitArgs(n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %s", n.Line, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("line %d: empty stage", n.Line)
+		}
+		s.Command, s.Args = args[0], args[1:]
+		return nil
+	}
+	var stage struct {
+		Command string       `yaml:"command"`
+		Args    values       `yaml:"args"`
+		Flags   orderedFlags `yaml:"flags"`
+	}
+	err := checkKeys(n, "command", "args", "flags")
+	if err == nil {
+		err = n.Decode(&stage)
+	}
+	if err != nil {
+		return err
+	}
+	if stage.Command == "" {
+		return fmt.Errorf("line %d: stage without command", n.Line)
+	}
+	s.Command, s.Args, s.Flags = stage.Command, stage.Args, stage.Flags
+	return nil
+}
+
+// orderedFlags decodes a mapping of flag names to values keeping its order
+type orderedFlags []Flag
+
+func (o *orderedFlags) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: flags must be a mapping of flag names to values", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var v values
+		err := n.Content[i+1].Decode(&v)
+		if err != nil {
+			return err
+		}
+		*o = append(*o, Flag{Name: n.Content[i].Value, Values: v})
+	}
+	return nil
+}
+
+// values decodes a value or a list of values
+type values []string
+
+func (v *values) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*v = []string{n.Value}
+		return nil
+	}
+	var list []string
+	err := n.Decode(&list)
+	if err != nil {
+		return fmt.Errorf("line %d: expected a value or a list of values", n.Line)
+	}
+	*v = list
+	return nil
+}
+
+// checkKeys fails on keys of a mapping that are not known. Decoding a node
+// ignores them while they are most likely a typo.
+func checkKeys(n *yaml.Node, known ...string) error {
+	if n.Kind != yaml.MappingNode {
+		//Decoding reports the mismatch
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !slices.Contains(known, n.Content[i].Value) {
+			return fmt.Errorf("line %d: unknown key %s", n.Content[i].Line, n.Content[i].Value)
+		}
+	}
+	return nil
+}
+
+// SplitArgs splits a command line into arguments like a shell would for
+// words, single and double quotes and backslash escapes.
+func SplitArgs(s string) ([]string, error) {
+	var args = make([]string, 0)
+	var current strings.Builder
+	var inArg bool
+	var quote rune
+	var escaped bool
+	for _, r := range s {
+		switch {
+		case escaped:
+			//Like a shell, within double quotes a backslash only escapes
+			//characters that are special there (e.g. "req=\w+" keeps it)
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %s", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pvbouwel/sp/config"
+)
+
+func TestParse(t *testing.T) {
+	//Given a config with both kinds of stages, quoting, comments, folded text and flow collections
+	data := `# profiles of sp
+profiles:
+  json-traffic:
+    description: "Colour JSON # not a comment"
+    stages:
+      - epoch --tz 'America/New_York' --keep-original   # a comment
+      - command: color
+        flags:
+          color-type: JSON
+          colors:
+          - info.0.255.0
+          - 'error.bg:red+bold'
+  hl:
+    stages: [ "highlight -e 'ERROR=red'" ]
+  folded:
+    description: >
+      Replace epochs
+      in local time
+    stages:
+      - {command: epoch, flags: {tz: local}}
+`
+	//WHEN it is parsed
+	c, err := config.Parse(data)
+	if err != nil {
+		t.Errorf("Could not parse config: %s", err)
+		t.FailNow()
+	}
+
+	//THEN the profiles are in order with their stages
+	expected := []config.Profile{
+		{
+			Name:        "json-traffic",
+			Description: "Colour JSON # not a comment",
+			Stages: []config.Stage{
+				{Command: "epoch", Args: []string{"--tz", "America/New_York", "--keep-original"}},
+				{Command: "color", Flags: []config.Flag{
+					{Name: "color-type", Values: []string{"JSON"}},
+					{Name: "colors", Values: []string{"info.0.255.0", "error.bg:red+bold"}},
+				}},
+			},
+		},
+		{
+			Name:   "hl",
+			Stages: []config.Stage{{Command: "highlight", Args: []string{"-e", "ERROR=red"}}},
+		},
+		{
+			Name:        "folded",
+			Description: "Replace epochs in local time",
+			Stages:      []config.Stage{{Command: "epoch", Flags: []config.Flag{{Name: "tz", Values: []string{"local"}}}}},
+		},
+	}
+	if !reflect.DeepEqual(c.Profiles, expected) {
+		t.Errorf("\nExpected:%+v\nGot     :%+v", expected, c.Profiles)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		"profiles:\n  a:\n    stages: []\n",
+		"profiles:\n  a:\n    stages:\n      - epoch 'unterminated\n",
+		"profiles:\n  a:\n    colors: red\n    stages: [epoch]\n",
+		"profiles:\n  a:\n    stages: [epoch]\n   b: 1\n",
+		"profiles:\n  a:\n    stages:\n      - flags:\n          tz: local\n",
+		"profiles:\n  a:\n    stages:\n      - {command: epoch, flag: {tz: local}}\n",
+		"profiles: [a, b]\n",
+		"profile:\n  a:\n    stages: [epoch]\n",
+	} {
+		//WHEN an invalid config is parsed THEN it fails
+		_, err := config.Parse(data)
+		if err == nil {
+			t.Errorf("Expected an error for:\n%s", data)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected []string
+	}{
+		{`highlight -e "req=\w+=cyan"`, []string{"highlight", "-e", `req=\w+=cyan`}},
+		{`highlight -e 'req=\w+=cyan'`, []string{"highlight", "-e", `req=\w+=cyan`}},
+		{`highlight -e req=\w+=cyan`, []string{"highlight", "-e", "req=w+=cyan"}},
+		{`echo "a \"b\" \\ \$HOME \` + "`" + `"`, []string{"echo", `a "b" \ $HOME ` + "`"}},
+		{`epoch  --tz\ x 'it''s'`, []string{"epoch", "--tz x", "its"}},
+	} {
+		//WHEN a command line is split
+		args, err := config.SplitArgs(tc.line)
+
+		//THEN the arguments are like those of a shell
+		if err != nil {
+			t.Errorf("Could not split %s: %s", tc.line, err)
+		}
+		if !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("\nExpected:%q\nGot     :%q", tc.expected, args)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	//Given a config file pointed to by the environment
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(path, []byte("profiles:\n  e:\n    stages: [epoch]\n"), 0o600)
+	if err != nil {
+		t.Errorf("Could not write config: %s", err)
+		t.FailNow()
+	}
+	t.Setenv(config.EnvConfig, path)
+
+	//WHEN it is loaded
+	c, err := config.Load(config.Path())
+
+	//THEN its profiles are there
+	if err != nil || len(c.Profiles) != 1 || c.Profiles[0].Name != "e" {
+		t.Errorf("Unexpected config %+v (error %v)", c, err)
+	}
+
+	//WHEN a config that does not exist is loaded THEN it is empty
+	c, err = config.Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil || len(c.Profiles) != 0 {
+		t.Errorf("Expected an empty config got %+v (error %v)", c, err)
+	}
+}
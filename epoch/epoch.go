@@ -4,70 +4,313 @@ Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
 package epoch
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	jsonwriter "github.com/pvbouwel/sp/json"
 )
 
-func bytesToInt64(bytes []byte) int64 {
-	i, err := strconv.ParseInt(string(bytes), 10, 64)
+// Precision of an epoch. The value is the number of digits of a present day
+// epoch in that precision which is how precisions are told apart.
+type Precision int
+
+const (
+	Seconds      Precision = 10
+	Milliseconds Precision = 13
+	Microseconds Precision = 16
+	Nanoseconds  Precision = 19
+)
+
+// Options decide which epochs get replaced and how
+type Options struct {
+	//The precisions that are detected, other runs of digits are left alone.
+	//In reverse the precisions that timestamps can be rendered in.
+	Precisions []Precision
+
+	//Replace human readable timestamps by epochs instead
+	Reverse bool
+
+	//A Go time layout or one of FormatPresets. Empty means ISO 8601 with the
+	//sub-second digits that the epoch has.
+	Format string
+
+	//The time zone to render in, nil means UTC. In reverse the time zone of
+	//timestamps that do not specify one.
+	Location *time.Location
+
+	//Keep the epoch and add the rendered time between brackets
+	KeepOriginal bool
+
+	//Only epochs within these bounds are replaced, a zero time means unbounded
+	Min time.Time
+	Max time.Time
+
+	//If not empty only values of JSON object members with these keys are
+	//considered. Everything outside of JSON objects is left alone.
+	JSONKeys []string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Precisions: []Precision{Seconds, Milliseconds, Microseconds, Nanoseconds},
+		Location:   time.UTC,
+	}
+}
+
+// Renders the time relative to now (e.g. 3m ago) instead of using a layout
+const FormatRelative = "relative"
+
+// Named formats that can be used instead of a Go time layout. Names are
+// matched ignoring case.
+var FormatPresets = map[string]string{
+	"ansic":       time.ANSIC,
+	"rfc822":      time.RFC822,
+	"rfc1123":     time.RFC1123,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"kitchen":     time.Kitchen,
+	"stamp":       time.Stamp,
+	"stampmilli":  time.StampMilli,
+	"datetime":    time.DateTime,
+	"dateonly":    time.DateOnly,
+	"timeonly":    time.TimeOnly,
+}
+
+// A complete run of digits optionally with a fraction (e.g. 1700000000.123).
+// Compiled once as Write is called for every line
+var epochRegexp = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+
+// toTime converts the digits of a candidate epoch into a time. It also returns
+// the number of sub-second digits that the epoch has.
+func (e *epoch) toTime(digits []byte, fraction []byte) (t time.Time, fractionDigits int, ok bool) {
+	precision := Precision(len(digits))
+	if !slices.Contains(e.options.Precisions, precision) {
+		return t, 0, false
+	}
+	if len(fraction) > 0 && (precision != Seconds || len(fraction) > 9) {
+		//Only seconds can have a fraction and it cannot be more precise than ns
+		return t, 0, false
+	}
+	v, err := strconv.ParseInt(string(digits), 10, 64)
 	if err != nil {
-		fmt.Printf("Could not parse int64 %s due to %s", string(bytes), err)
+		return t, 0, false
+	}
+	t, fractionDigits, ok = toPrecisionTime(v, precision, fraction)
+	if !ok || !e.inBounds(t) {
+		return t, 0, false
 	}
-	return i
+	return t, fractionDigits, true
 }
 
-func replaceIfEpoch(bytes []byte) string {
-	r, _ := regexp.Compile(`\.`)
-	loc := r.FindIndex(bytes)
-	var t time.Time
-	var sec, nsec int64
-	var secEndIdx int
-	if loc == nil {
-		// No dot
-		nsec = 0
-		secEndIdx = len(bytes)
+func toPrecisionTime(v int64, precision Precision, fraction []byte) (t time.Time, fractionDigits int, ok bool) {
+	switch precision {
+	case Seconds:
+		var nsec int64
+		var err error
+		if len(fraction) > 0 {
+			nsec, err = strconv.ParseInt(string(fraction)+strings.Repeat("0", 9-len(fraction)), 10, 64)
+			if err != nil {
+				return t, 0, false
+			}
+		}
+		return time.Unix(v, nsec), len(fraction), true
+	case Milliseconds:
+		return time.UnixMilli(v), 3, true
+	case Microseconds:
+		return time.UnixMicro(v), 6, true
+	case Nanoseconds:
+		return time.Unix(0, v), 9, true
+	}
+	return t, 0, false
+}
+
+// defaultLayout returns the ISO 8601 layout to render a time with the given
+// sub-second digits
+func defaultLayout(fractionDigits int) string {
+	if fractionDigits == 0 {
+		return "2006-01-02T15:04:05Z07:00"
+	}
+	return "2006-01-02T15:04:05." + strings.Repeat("0", fractionDigits) + "Z07:00"
+}
+
+// relative renders how long ago (or how far in the future) t is in the
+// largest unit that fits (e.g. 3m ago or in 2d).
+func relative(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	var future = d < 0
+	if future {
+		d = -d
+	}
+	var amount string
+	switch {
+	case d < time.Minute:
+		amount = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		amount = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 365*24*time.Hour:
+		amount = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	default:
+		amount = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+func (e *epoch) render(t time.Time, fractionDigits int) string {
+	if e.options.Location != nil {
+		t = t.In(e.options.Location)
 	} else {
-		secEndIdx = loc[0]
-		nsec = bytesToInt64(bytes[loc[1]:])
+		t = t.UTC()
+	}
+	if strings.EqualFold(e.options.Format, FormatRelative) {
+		return relative(t, time.Now())
 	}
-	sec = bytesToInt64(bytes[0:secEndIdx])
+	if e.options.Format == "" {
+		return t.Format(defaultLayout(fractionDigits))
+	}
+	if layout, ok := FormatPresets[strings.ToLower(e.options.Format)]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(e.options.Format)
+}
 
-	t = time.Unix(sec, nsec)
+func (e *epoch) replaceIfEpoch(match []byte) []byte {
+	digits, fraction, _ := bytes.Cut(match, []byte("."))
+	t, fractionDigits, ok := e.toTime(digits, fraction)
+	if !ok {
+		return match
+	}
+	rendered := e.render(t, fractionDigits)
+	if e.options.KeepOriginal {
+		return []byte(fmt.Sprintf("%s (%s)", match, rendered))
+	}
+	return []byte(rendered)
+}
 
-	return t.UTC().Format("2006-01-02T15:04:05Z")
+func isAlphanumeric(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
 }
 
-func replaceEpochs(line []byte) []byte {
-	r, _ := regexp.Compile(`[0-9]{10}`)
-	loc := r.FindIndex(line)
-	if loc == nil {
+// isWord tells whether line[start:end] is not part of a longer alphanumeric
+// token such as an order ID or part of a UUID.
+func isWord(line []byte, start int, end int) bool {
+	if start > 0 && isAlphanumeric(line[start-1]) {
+		return false
+	}
+	if end < len(line) && isAlphanumeric(line[end]) {
+		return false
+	}
+	return true
+}
+
+// replaceWords replaces every match of re that is not part of a longer
+// alphanumeric token by the result of replace.
+func replaceWords(line []byte, re *regexp.Regexp, replace func([]byte) []byte) []byte {
+	locs := re.FindAllIndex(line, -1)
+	if locs == nil {
 		return line
-	} else {
-		var rewrite = make([]byte, 0)
+	}
+	var rewrite = make([]byte, 0, len(line))
+	var last int
+	for _, loc := range locs {
+		rewrite = append(rewrite, line[last:loc[0]]...)
+		if isWord(line, loc[0], loc[1]) {
+			rewrite = append(rewrite, replace(line[loc[0]:loc[1]])...)
+		} else {
+			rewrite = append(rewrite, line[loc[0]:loc[1]]...)
+		}
+		last = loc[1]
+	}
+	return append(rewrite, line[last:]...)
+}
+
+// inBounds tells whether t is within Min and Max
+func (e *epoch) inBounds(t time.Time) bool {
+	if !e.options.Min.IsZero() && t.Before(e.options.Min) {
+		return false
+	}
+	if !e.options.Max.IsZero() && t.After(e.options.Max) {
+		return false
+	}
+	return true
+}
 
-		rewrite = append(rewrite, line[0:loc[0]]...)
-		rewrite = append(rewrite, []byte(replaceIfEpoch(line[loc[0]:loc[1]]))...)
-		rewrite = append(rewrite, line[loc[1]:]...)
-		return rewrite
+func (e *epoch) replace(line []byte) []byte {
+	if e.options.Reverse {
+		return replaceWords(line, timestampRegexp, e.replaceIfTimestamp)
 	}
+	return replaceWords(line, epochRegexp, e.replaceIfEpoch)
+}
+
+// replaceJSONKeyEpochs only replaces in values of the configured keys
+// of a possible JSON object.
+func (e *epoch) replaceJSONKeyEpochs(object []byte) []byte {
+	values, err := jsonwriter.ScalarValues(object)
+	if err != nil {
+		//Not JSON after all so nothing to replace
+		return object
+	}
+	var rewrite = make([]byte, 0, len(object))
+	var last int
+	for _, value := range values {
+		if !slices.Contains(e.options.JSONKeys, value.Key) {
+			continue
+		}
+		rewrite = append(rewrite, object[last:value.Start]...)
+		rewrite = append(rewrite, e.replace(object[value.Start:value.End])...)
+		last = value.End
+	}
+	return append(rewrite, object[last:]...)
 }
 
 type epoch struct {
 	wrapped io.Writer
+
+	options Options
+
+	//Finds the JSON objects when only JSON keys are considered
+	jsonWriter io.Writer
 }
 
-func (e epoch) Write(b []byte) (n int, err error) {
+// epochJSONWriter gets the possible JSON objects found in the input
+type epochJSONWriter struct {
+	e *epoch
+}
+
+func (j *epochJSONWriter) Write(b []byte) (n int, err error) {
+	n = len(b)
+
+	_, err = j.e.wrapped.Write(j.e.replaceJSONKeyEpochs(b))
+	return
+}
+
+func (e *epoch) Write(b []byte) (n int, err error) {
+	if e.jsonWriter != nil {
+		return e.jsonWriter.Write(b)
+	}
 	n = len(b)
 
-	_, err = e.wrapped.Write(replaceEpochs(b))
+	_, err = e.wrapped.Write(e.replace(b))
 	return
 }
 
-func NewEpoch(w io.Writer) *epoch {
-	return &epoch{
+func NewEpoch(w io.Writer, options Options) *epoch {
+	var e = &epoch{
 		wrapped: w,
+		options: options,
+	}
+	if len(options.JSONKeys) > 0 {
+		e.jsonWriter = jsonwriter.NewEnclosedWriter(w, &epochJSONWriter{e: e})
 	}
+	return e
 }
@@ -0,0 +1,209 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package epoch_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pvbouwel/sp/epoch"
+)
+
+func TestEpochReplacesAllOccurrences(t *testing.T) {
+	//Given a buffer to write into
+	rb := new(bytes.Buffer)
+
+	//WHEN we write a line with multiple epochs
+	w := epoch.NewEpoch(rb, epoch.DefaultOptions())
+	_, err := w.Write([]byte("{\"start\":1700000000,\"end\":1700000100}"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN all of them are replaced
+	expectedLine := "{\"start\":2023-11-14T22:13:20Z,\"end\":2023-11-14T22:15:00Z}"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+}
+
+func TestEpochWithoutEpoch(t *testing.T) {
+	//Given a buffer to write into
+	rb := new(bytes.Buffer)
+
+	//WHEN we write a line without epochs
+	w := epoch.NewEpoch(rb, epoch.DefaultOptions())
+	_, err := w.Write([]byte("nothing to see 12345"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN it is left alone
+	expectedLine := "nothing to see 12345"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+}
+
+func TestEpochPrecisions(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"s=1700000000", "s=2023-11-14T22:13:20Z"},
+		{"s=1700000000.5", "s=2023-11-14T22:13:20.5Z"},
+		{"ms=1700000000123", "ms=2023-11-14T22:13:20.123Z"},
+		{"us=1700000000123456", "us=2023-11-14T22:13:20.123456Z"},
+		{"ns=1700000000123456789", "ns=2023-11-14T22:13:20.123456789Z"},
+		{"other=17000000001", "other=17000000001"},
+	}
+	for i, test := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write a line with an epoch
+		w := epoch.NewEpoch(rb, epoch.DefaultOptions())
+		_, err := w.Write([]byte(test.input))
+		if err != nil {
+			t.Errorf("%d: Encountered error when writing msg: %s", i, err)
+		}
+
+		//THEN it is replaced keeping the sub-second part
+		if rb.String() != test.expected {
+			t.Errorf("\n%d: Expected:%s\nGot     :%s", i, test.expected, rb.String())
+		}
+	}
+}
+
+func TestEpochDisabledPrecision(t *testing.T) {
+	//Given a buffer to write into
+	rb := new(bytes.Buffer)
+
+	//WHEN milliseconds are not detected
+	w := epoch.NewEpoch(rb, epoch.Options{Precisions: []epoch.Precision{epoch.Seconds}})
+	_, err := w.Write([]byte("1700000000 1700000000123"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN only seconds are replaced
+	expectedLine := "2023-11-14T22:13:20Z 1700000000123"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+}
+
+func TestEpochFormatAndTimeZone(t *testing.T) {
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Skipf("No time zone database: %s", err)
+	}
+	var tests = []struct {
+		options  epoch.Options
+		expected string
+	}{
+		{epoch.Options{Precisions: []epoch.Precision{epoch.Seconds}, Location: brussels}, "at 2023-11-14T23:13:20+01:00"},
+		{epoch.Options{Precisions: []epoch.Precision{epoch.Seconds}, Format: "RFC1123"}, "at Tue, 14 Nov 2023 22:13:20 UTC"},
+		{epoch.Options{Precisions: []epoch.Precision{epoch.Seconds}, Format: "15:04"}, "at 22:13"},
+		{epoch.Options{Precisions: []epoch.Precision{epoch.Seconds}, KeepOriginal: true}, "at 1700000000 (2023-11-14T22:13:20Z)"},
+	}
+	for i, test := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write an epoch with the given options
+		w := epoch.NewEpoch(rb, test.options)
+		_, err := w.Write([]byte("at 1700000000"))
+		if err != nil {
+			t.Errorf("%d: Encountered error when writing msg: %s", i, err)
+		}
+
+		//THEN it is rendered accordingly
+		if rb.String() != test.expected {
+			t.Errorf("\n%d: Expected:%s\nGot     :%s", i, test.expected, rb.String())
+		}
+	}
+}
+
+func TestEpochPlausibility(t *testing.T) {
+	var secondsOnly = []epoch.Precision{epoch.Seconds}
+	var tests = []struct {
+		options  epoch.Options
+		input    string
+		expected string
+	}{
+		{
+			epoch.Options{Precisions: secondsOnly},
+			"order1700000000 1700000000abc id-1700000000",
+			"order1700000000 1700000000abc id-2023-11-14T22:13:20Z",
+		},
+		{
+			epoch.Options{Precisions: secondsOnly, Min: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), Max: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+			"0900000000 1700000000 9999999999",
+			"0900000000 2023-11-14T22:13:20Z 9999999999",
+		},
+		{
+			epoch.Options{Precisions: secondsOnly, JSONKeys: []string{"ts", "time"}},
+			"1700000000 {\"ts\": 1700000000, \"id\": 1700000000, \"nested\": {\"time\": \"1700000000\"}}",
+			"1700000000 {\"ts\": 2023-11-14T22:13:20Z, \"id\": 1700000000, \"nested\": {\"time\": \"2023-11-14T22:13:20Z\"}}",
+		},
+	}
+	for i, test := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write a line with candidate epochs
+		w := epoch.NewEpoch(rb, test.options)
+		_, err := w.Write([]byte(test.input))
+		if err != nil {
+			t.Errorf("%d: Encountered error when writing msg: %s", i, err)
+		}
+
+		//THEN only the plausible ones are replaced
+		if rb.String() != test.expected {
+			t.Errorf("\n%d: Expected:%s\nGot     :%s", i, test.expected, rb.String())
+		}
+	}
+}
+
+func TestEpochReverse(t *testing.T) {
+	var tests = []struct {
+		options  epoch.Options
+		input    string
+		expected string
+	}{
+		{
+			epoch.Options{Reverse: true, Precisions: []epoch.Precision{epoch.Seconds, epoch.Milliseconds}},
+			"at 2023-11-14T22:13:20Z and 2023-11-14 23:13:20,123 +0100",
+			"at 1700000000 and 1700000000123",
+		},
+		{
+			epoch.Options{Reverse: true, Precisions: []epoch.Precision{epoch.Seconds}},
+			"[14/Nov/2023:23:13:20 +0100] 2023-11-14T22:13:20.5",
+			"[1700000000] 1700000000",
+		},
+		{
+			epoch.Options{Reverse: true, Precisions: []epoch.Precision{epoch.Milliseconds}, KeepOriginal: true},
+			"at 2023-11-14T22:13:20Z",
+			"at 2023-11-14T22:13:20Z (1700000000000)",
+		},
+	}
+	for i, test := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write a line with timestamps in reverse
+		w := epoch.NewEpoch(rb, test.options)
+		_, err := w.Write([]byte(test.input))
+		if err != nil {
+			t.Errorf("%d: Encountered error when writing msg: %s", i, err)
+		}
+
+		//THEN they are replaced by epochs
+		if rb.String() != test.expected {
+			t.Errorf("\n%d: Expected:%s\nGot     :%s", i, test.expected, rb.String())
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package epoch
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Human readable timestamps that are turned into epochs in reverse. Each
+// alternative is a capture group so the matching format is known.
+var timestampRegexp = regexp.MustCompile(
+	// RFC3339 and ISO 8601 (e.g. 2023-11-14T22:13:20.123Z or 2023-11-14 22:13:20,123 +0100)
+	`([0-9]{4}-[0-9]{2}-[0-9]{2}[T ][0-9]{2}:[0-9]{2}:[0-9]{2}(?:[.,]([0-9]{1,9}))?(?: ?(?:Z|[+-][0-9]{2}:?[0-9]{2}))?)` +
+		// Apache common log format (e.g. 14/Nov/2023:22:13:20 +0000)
+		`|([0-9]{2}/[A-Z][a-z]{2}/[0-9]{4}:[0-9]{2}:[0-9]{2}:[0-9]{2} [+-][0-9]{4})` +
+		// Syslog (e.g. Nov 14 22:13:20) which has no year
+		`|([A-Z][a-z]{2} [ 0-3][0-9] [0-9]{2}:[0-9]{2}:[0-9]{2})`,
+)
+
+const (
+	isoGroup      = 1
+	fractionGroup = 2
+	clfGroup      = 3
+	syslogGroup   = 4
+)
+
+// Layouts of ISO 8601 timestamps after normalizing the separators
+var isoZoneLayouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05Z0700",
+}
+
+const isoLayout = "2006-01-02T15:04:05"
+const clfLayout = "02/Jan/2006:15:04:05 -0700"
+const syslogLayout = "Jan _2 15:04:05"
+
+func (e *epoch) location() *time.Location {
+	if e.options.Location == nil {
+		return time.UTC
+	}
+	return e.options.Location
+}
+
+func parseISO(timestamp string, loc *time.Location) (time.Time, error) {
+	//Date and time can be separated by a space and the zone as well
+	timestamp = timestamp[0:10] + "T" + strings.Replace(timestamp[11:], " ", "", 1)
+	timestamp = strings.Replace(timestamp, ",", ".", 1)
+	for _, layout := range isoZoneLayouts {
+		t, err := time.Parse(layout, timestamp)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.ParseInLocation(isoLayout, timestamp, loc)
+}
+
+// parseSyslog assumes the most recent year for which the timestamp is not in
+// the future (allowing a day of clock skew).
+func parseSyslog(timestamp string, loc *time.Location, now time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation(syslogLayout, timestamp, loc)
+	if err != nil {
+		return t, err
+	}
+	now = now.In(loc)
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+	if t.After(now.AddDate(0, 0, 1)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, nil
+}
+
+// reversePrecision picks the precision to render a timestamp with the given
+// sub-second digits in. That is the coarsest enabled precision which keeps all
+// the digits or otherwise the finest enabled one.
+func (e *epoch) reversePrecision(fractionDigits int) Precision {
+	var enabled = make([]Precision, 0)
+	for _, precision := range []Precision{Seconds, Milliseconds, Microseconds, Nanoseconds} {
+		if slices.Contains(e.options.Precisions, precision) {
+			enabled = append(enabled, precision)
+		}
+	}
+	if len(enabled) == 0 {
+		return Seconds
+	}
+	for _, precision := range enabled {
+		if int(precision-Seconds) >= fractionDigits {
+			return precision
+		}
+	}
+	return enabled[len(enabled)-1]
+}
+
+func toEpoch(t time.Time, precision Precision) string {
+	switch precision {
+	case Milliseconds:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case Microseconds:
+		return strconv.FormatInt(t.UnixMicro(), 10)
+	case Nanoseconds:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func (e *epoch) replaceIfTimestamp(match []byte) []byte {
+	groups := timestampRegexp.FindSubmatch(match)
+	var t time.Time
+	var err error
+	var fractionDigits int
+	switch {
+	case groups[isoGroup] != nil:
+		fractionDigits = len(groups[fractionGroup])
+		t, err = parseISO(string(match), e.location())
+	case groups[clfGroup] != nil:
+		t, err = time.Parse(clfLayout, string(match))
+	case groups[syslogGroup] != nil:
+		t, err = parseSyslog(string(match), e.location(), time.Now())
+	}
+	if err != nil || !e.inBounds(t) {
+		return match
+	}
+	converted := toEpoch(t, e.reversePrecision(fractionDigits))
+	if e.options.KeepOriginal {
+		return []byte(fmt.Sprintf("%s (%s)", match, converted))
+	}
+	return []byte(converted)
+}
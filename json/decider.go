@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// Style renders (part of) a JSON object for which a decision was made
+type Style interface {
+	Write(w io.Writer, p []byte) (n int, err error)
+}
+
+// ColorStyle renders in a single colour
+type ColorStyle struct {
+	Color *color.Color
+}
+
+func (s ColorStyle) Write(w io.Writer, p []byte) (n int, err error) {
+	s.Color.SetWriter(w)
+	n, err = w.Write(p)
+	s.Color.UnsetWriter(w)
+	return n, err
+}
+
+// Decision is the outcome of a ColourDecider for a JSON object
+type Decision struct {
+	//How to render the object, nil means the object is written as is
+	Style Style
+
+	//Describes what matched (e.g. a JSONColor rule), nil if nothing did
+	Rule fmt.Stringer
+
+	//Key or path of the field that led to the decision, empty if the decision
+	//is not based on a single field
+	Key string
+}
+
+// ColourDecider decides how a decoded JSON object gets rendered. It can be
+// implemented outside of this package and made available by name with
+// RegisterColourDecider.
+type ColourDecider interface {
+	Decide(m map[string]any) Decision
+}
+
+// DeciderConfig is the configuration from which a registered decider is made
+type DeciderConfig struct {
+	//Keys or paths of the fields that decide, in order of preference
+	Keys []string
+
+	//Rules that map field values to colours
+	Rules []JSONColor
+
+	//Colours for deciders that pick colours themselves
+	Palette []*color.Color
+
+	//Do not consider cases when matching
+	IgnoreCase bool
+}
+
+type DeciderFactory func(config DeciderConfig) (ColourDecider, error)
+
+var deciderFactories = map[string]DeciderFactory{}
+
+// The decider that colours by rules, see NewMapBasedColourDecider
+const RulesDecider = "rules"
+
+// RegisterColourDecider makes a decider available under name. Registering a
+// name again replaces the earlier factory.
+func RegisterColourDecider(name string, factory DeciderFactory) {
+	deciderFactories[name] = factory
+}
+
+// NewColourDecider creates the decider registered under name
+func NewColourDecider(name string, config DeciderConfig) (ColourDecider, error) {
+	factory, ok := deciderFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown colour decider %s", name)
+	}
+	return factory(config)
+}
+
+// ColourDeciders returns the names of all registered deciders
+func ColourDeciders() []string {
+	var names = make([]string, 0, len(deciderFactories))
+	for name := range deciderFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterColourDecider(RulesDecider, func(config DeciderConfig) (ColourDecider, error) {
+		if len(config.Rules) == 0 {
+			return nil, fmt.Errorf("the %s colour decider requires rules", RulesDecider)
+		}
+		d := NewMapBasedColourDecider(config.IgnoreCase, config.Rules...)
+		return &d, nil
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A field of an object in the order it appears
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// members returns the members of the JSON object p in the order they appear
+func members(p []byte) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	var result = make([]member, 0)
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var m = member{key: tok.(string)}
+		err = dec.Decode(&m.value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	_, err = dec.Token()
+	return result, err
+}
+
+// A field to project on. A field written with a trailing = (e.g. err=) is
+// labelled with its key in human readable output.
+type projectedField struct {
+	path     Path
+	labelled bool
+}
+
+func parseFields(fields []string) []projectedField {
+	var result = make([]projectedField, 0, len(fields))
+	for _, field := range fields {
+		var f projectedField
+		field, f.labelled = strings.CutSuffix(field, "=")
+		f.path = pathOrKey(field)
+		result = append(result, f)
+	}
+	return result
+}
+
+// projects tells whether the top level key is shown by one of the fields
+func projects(fields []projectedField, key string) bool {
+	for _, f := range fields {
+		if f.path.raw == key || (len(f.path.elements) > 0 && f.path.elements[0].key == key && !f.path.elements[0].isIndex) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeValue(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), err
+}
+
+// humanValue renders a value for human readable output. Objects and arrays
+// stay JSON, when quote is set strings that would be ambiguous are quoted.
+func humanValue(v any, quote bool) string {
+	switch t := v.(type) {
+	case map[string]any, []any:
+		encoded, err := encodeValue(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(encoded)
+	case string:
+		if quote && (t == "" || strings.ContainsAny(t, " =\"\t\n")) {
+			return strconv.Quote(t)
+		}
+		return t
+	}
+	return valueText(v)
+}
+
+// project keeps the selected fields of the JSON object p in their order
+// followed by the others if Tail is set or there are no selected fields. The result is JSON unless Human is
+// set in which case it is a line with the values followed by key=value pairs.
+func (o FormatOptions) project(p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var decoded map[string]any
+	err := dec.Decode(&decoded)
+	if err != nil {
+		return nil, err
+	}
+	all, err := members(p)
+	if err != nil {
+		return nil, err
+	}
+	fields := parseFields(o.Fields)
+
+	var parts = make([]string, 0)
+	for _, f := range fields {
+		v, ok := f.path.Lookup(decoded)
+		if !ok {
+			continue
+		}
+		if !o.Human {
+			key, _ := encodeValue(f.path.raw)
+			value, err := encodeValue(v)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, string(key)+":"+string(value))
+		} else if f.labelled {
+			parts = append(parts, f.path.raw+"="+humanValue(v, true))
+		} else {
+			parts = append(parts, humanValue(v, false))
+		}
+	}
+	//Without fields there is only a tail
+	if o.Tail || len(fields) == 0 {
+		for _, m := range all {
+			if projects(fields, m.key) {
+				continue
+			}
+			if !o.Human {
+				key, _ := encodeValue(m.key)
+				parts = append(parts, string(key)+":"+string(m.value))
+			} else {
+				parts = append(parts, m.key+"="+humanValue(decoded[m.key], true))
+			}
+		}
+	}
+	if o.Human {
+		return []byte(strings.Join(parts, " ")), nil
+	}
+	return []byte("{" + strings.Join(parts, ",") + "}"), nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// FilterOptions define what a filter writer lets through
+type FilterOptions struct {
+	//Let lines through that do not contain a JSON object
+	PassNonJSON bool
+
+	//Only drop the JSON objects that do not match instead of their whole line
+	Objects bool
+}
+
+// Lines are held back up to this size to decide on them. A longer line is
+// passed on unfiltered such that memory usage stays bounded.
+const maxFilteredLineLength = 1024 * 1024
+
+// filterWriter passes on the lines of which a JSON object matches a
+// predicate. Lines are kept until their newline arrives such that they can
+// be dropped as a whole. Like the input the content of a line and its newline
+// are written separately.
+type filterWriter struct {
+	wrapped   io.Writer
+	predicate Predicate
+	options   FilterOptions
+
+	//The line until its newline arrives
+	buf []byte
+
+	//The current line got too long to decide on and is passed on as is
+	passing bool
+}
+
+func NewFilterWriter(w io.Writer, predicate Predicate, options FilterOptions) io.Writer {
+	return &filterWriter{
+		wrapped:   w,
+		predicate: predicate,
+		options:   options,
+	}
+}
+
+func (f *filterWriter) Write(p []byte) (n int, err error) {
+	for n < len(p) {
+		idx := bytes.IndexByte(p[n:], '\n')
+		if idx == -1 {
+			err = f.hold(p[n:])
+			if err != nil {
+				return n, err
+			}
+			return len(p), nil
+		}
+		err = f.hold(p[n : n+idx])
+		n += idx + 1
+		if err == nil && f.passing {
+			f.passing = false
+			_, err = f.wrapped.Write([]byte("\n"))
+		} else if err == nil {
+			err = f.endLine(true)
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// hold keeps part of a line or passes it on if the line is too long.
+func (f *filterWriter) hold(p []byte) error {
+	if !f.passing && len(f.buf)+len(p) <= maxFilteredLineLength {
+		f.buf = append(f.buf, p...)
+		return nil
+	}
+	f.passing = true
+	if len(f.buf) > 0 {
+		_, err := f.wrapped.Write(f.buf)
+		f.buf = f.buf[:0]
+		if err != nil {
+			return err
+		}
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	_, err := f.wrapped.Write(p)
+	return err
+}
+
+// Flush decides on a last line that did not end with a newline.
+func (f *filterWriter) Flush() error {
+	f.passing = false
+	if len(f.buf) == 0 {
+		return nil
+	}
+	return f.endLine(false)
+}
+
+func (f *filterWriter) endLine(newline bool) error {
+	defer func() { f.buf = f.buf[:0] }()
+	var objects = &matchingJSONWriter{predicate: f.predicate, dropNonMatching: f.options.Objects}
+	var line bytes.Buffer
+	objects.wrapped = &line
+	_, err := NewEnclosedWriter(&line, objects).Write(f.buf)
+	if err != nil {
+		return err
+	}
+	if !objects.sawJSON && !f.options.PassNonJSON {
+		return nil
+	}
+	if objects.sawJSON && !objects.matched {
+		return nil
+	}
+	_, err = f.wrapped.Write(line.Bytes())
+	if err != nil || !newline {
+		return err
+	}
+	_, err = f.wrapped.Write([]byte("\n"))
+	return err
+}
+
+// matchingJSONWriter records whether the JSON objects it gets match
+type matchingJSONWriter struct {
+	wrapped   io.Writer
+	predicate Predicate
+
+	//Leave out the objects that do not match
+	dropNonMatching bool
+
+	sawJSON bool
+	matched bool
+}
+
+func (m *matchingJSONWriter) Write(p []byte) (n int, err error) {
+	var decoded map[string]any
+	err = json.Unmarshal(p, &decoded)
+	if err != nil {
+		//Unsupported JSON is just text
+		return m.wrapped.Write(p)
+	}
+	m.sawJSON = true
+	if m.predicate.Matches(decoded) {
+		m.matched = true
+	} else if m.dropNonMatching {
+		return len(p), nil
+	}
+	return m.wrapped.Write(p)
+}
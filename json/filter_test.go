@@ -0,0 +1,144 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	jsonwriter "github.com/pvbouwel/sp/json"
+	"github.com/pvbouwel/sp/streams"
+)
+
+func TestFilter(t *testing.T) {
+	var lines = []string{
+		"{\"level\":\"warn\",\"latency_ms\":300}",
+		"{\"level\":\"info\",\"latency_ms\":300}",
+		"plain text",
+		"pre {\"level\":\"ERROR\",\"latency_ms\":201} {\"level\":\"info\"} post",
+		"{\"level\":\"warn\",\"latency_ms\":100}",
+	}
+	var tests = []struct {
+		name       string
+		expr       string
+		ignoreCase bool
+		options    jsonwriter.FilterOptions
+		expected   string
+	}{
+		{"lines", "level in (warn,error) && latency_ms > 200", false, jsonwriter.FilterOptions{PassNonJSON: true},
+			lines[0] + "\n" + lines[2] + "\n"},
+		{"ignore case", "level in (warn, error) && latency_ms > 200", true, jsonwriter.FilterOptions{},
+			lines[0] + "\n" + lines[3] + "\n"},
+		{"objects", "level=info || latency_ms>=300", false, jsonwriter.FilterOptions{Objects: true},
+			lines[0] + "\n" + lines[1] + "\npre  {\"level\":\"info\"} post\n"},
+	}
+	for _, tc := range tests {
+		//Given a filter writer
+		predicate, err := jsonwriter.ParsePredicate(tc.expr, tc.ignoreCase)
+		if err != nil {
+			t.Errorf("%s: could not parse %s: %s", tc.name, tc.expr, err)
+			continue
+		}
+		rb := new(bytes.Buffer)
+		w := jsonwriter.NewFilterWriter(rb, predicate, tc.options)
+
+		//WHEN lines are written with their newline separately
+		for _, line := range lines {
+			_, err = w.Write([]byte(line))
+			if err == nil {
+				_, err = w.Write([]byte("\n"))
+			}
+			if err != nil {
+				t.Errorf("%s: encountered error when writing msg: %s", tc.name, err)
+			}
+		}
+
+		//THEN only the lines of interest are passed on
+		if rb.String() != tc.expected {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.name, tc.expected, rb.String())
+		}
+	}
+}
+
+func TestFilterFlushesLastLine(t *testing.T) {
+	for _, tc := range []struct {
+		last     string
+		expected string
+	}{
+		{"{\"level\":\"error\"}", "{\"level\":\"error\"}\n{\"level\":\"error\"}"},
+		{"{\"level\":\"info\"}", "{\"level\":\"error\"}\n"},
+	} {
+		//Given a filter writer
+		predicate, err := jsonwriter.ParsePredicate("level=error", false)
+		if err != nil {
+			t.Errorf("Could not parse predicate: %s", err)
+			t.FailNow()
+		}
+		rb := new(bytes.Buffer)
+		w := jsonwriter.NewFilterWriter(rb, predicate, jsonwriter.FilterOptions{})
+
+		//WHEN the input ends with a line without newline and gets flushed
+		_, err = w.Write([]byte("{\"level\":\"error\"}\n" + tc.last))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+		err = streams.Flush(w)
+		if err != nil {
+			t.Errorf("Encountered error when flushing: %s", err)
+		}
+
+		//THEN the last line is filtered like the others without adding a newline
+		if rb.String() != tc.expected {
+			t.Errorf("\nExpected:%q\nGot     :%q", tc.expected, rb.String())
+		}
+	}
+}
+
+func TestFilterPassesLongLines(t *testing.T) {
+	//Given a filter writer
+	predicate, err := jsonwriter.ParsePredicate("level=error", false)
+	if err != nil {
+		t.Errorf("Could not parse predicate: %s", err)
+		t.FailNow()
+	}
+	rb := new(bytes.Buffer)
+	w := jsonwriter.NewFilterWriter(rb, predicate, jsonwriter.FilterOptions{})
+
+	//WHEN a line that is too long to hold back is written in fragments
+	longLine := "{\"level\":\"info\",\"pad\":\"" + strings.Repeat("a", 2*1024*1024) + "\"}"
+	for i := 0; i < len(longLine); i += 512 * 1024 {
+		_, err = w.Write([]byte(longLine[i:min(i+512*1024, len(longLine))]))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+	}
+
+	//THEN it is passed on before its newline arrives
+	if rb.Len() == 0 {
+		t.Errorf("Expected the long line to be passed on before its end")
+	}
+
+	//WHEN the line ends and shorter lines follow
+	_, err = w.Write([]byte("\n{\"level\":\"info\"}\n{\"level\":\"error\"}\n"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN the long line is passed on unfiltered and the others are filtered again
+	expected := longLine + "\n{\"level\":\"error\"}\n"
+	if rb.String() != expected {
+		t.Errorf("\nExpected:%d bytes ending in %q\nGot     :%d bytes ending in %q", len(expected), expected[len(expected)-40:], rb.Len(), rb.String()[max(0, rb.Len()-40):])
+	}
+}
+
+func TestInvalidPredicates(t *testing.T) {
+	for _, expr := range []string{"info", "latency_ms > fast", "msg~(", "level in (warn) && "} {
+		//WHEN an invalid expression is parsed THEN it fails
+		_, err := jsonwriter.ParsePredicate(expr, false)
+		if err == nil {
+			t.Errorf("Expected an error for %s", expr)
+		}
+	}
+}
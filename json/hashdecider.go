@@ -0,0 +1,74 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
+)
+
+// The decider that colours by a hash of a field value, see NewHashColourDecider
+const HashDecider = "hash"
+
+// hashRule describes a decision of the hashColourDecider
+type hashRule struct {
+	key   string
+	value string
+}
+
+func (r hashRule) String() string {
+	return fmt.Sprintf("hash(%s=%s)", r.key, r.value)
+}
+
+// hashColourDecider gives every distinct value of a field a stable colour such
+// that all objects with the same value (e.g. a trace_id) share their colour.
+type hashColourDecider struct {
+	paths   []Path
+	palette []*color.Color
+}
+
+// NewHashColourDecider colours by the value of the first of the keys that an
+// object has.
+func NewHashColourDecider(palette []*color.Color, keys ...string) ColourDecider {
+	var d = &hashColourDecider{palette: palette}
+	for _, key := range keys {
+		d.paths = append(d.paths, pathOrKey(key))
+	}
+	return d
+}
+
+func (d *hashColourDecider) Decide(m map[string]any) Decision {
+	for _, path := range d.paths {
+		value, ok := path.Lookup(m)
+		if !ok || value == nil {
+			continue
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			//Only scalar values identify something
+			continue
+		}
+		text := valueText(value)
+		return Decision{
+			Style: ColorStyle{Color: c.HashColor(d.palette, []byte(text))},
+			Rule:  hashRule{key: path.String(), value: text},
+			Key:   path.String(),
+		}
+	}
+	return Decision{}
+}
+
+func init() {
+	RegisterColourDecider(HashDecider, func(config DeciderConfig) (ColourDecider, error) {
+		if len(config.Palette) == 0 {
+			return nil, fmt.Errorf("the %s colour decider requires colours", HashDecider)
+		}
+		if len(config.Keys) == 0 {
+			return nil, fmt.Errorf("the %s colour decider requires keys", HashDecider)
+		}
+		return NewHashColourDecider(config.Palette, config.Keys...), nil
+	})
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/fatih/color"
+)
+
+// HighlightStyles are the colours used to syntax highlight JSON. A nil colour
+// leaves that kind of token alone.
+type HighlightStyles struct {
+	Key    *color.Color
+	String *color.Color
+	Number *color.Color
+	Bool   *color.Color
+	Null   *color.Color
+}
+
+func DefaultHighlightStyles() HighlightStyles {
+	return HighlightStyles{
+		Key:    color.New(color.FgBlue, color.Bold),
+		String: color.New(color.FgGreen),
+		Number: color.New(color.FgCyan),
+		Bool:   color.New(color.FgYellow),
+		Null:   color.New(color.FgHiBlack),
+	}
+}
+
+func (s HighlightStyles) get(kind tokenKind) *color.Color {
+	switch kind {
+	case keyToken:
+		return s.Key
+	case stringToken:
+		return s.String
+	case numberToken:
+		return s.Number
+	case boolToken:
+		return s.Bool
+	}
+	return s.Null
+}
+
+// highlightJSONWriter syntax highlights JSON objects. The field that a
+// decision was based on gets the style of the decision instead.
+type highlightJSONWriter struct {
+	wrapped io.Writer
+
+	//Optional, without a decider only syntax highlighting is done
+	colourDecider ColourDecider
+
+	styles HighlightStyles
+}
+
+func NewHighlightJSONWriter(w io.Writer, c ColourDecider, styles HighlightStyles) io.Writer {
+	return NewEnclosedWriter(w, &highlightJSONWriter{
+		wrapped:       w,
+		colourDecider: c,
+		styles:        styles,
+	})
+}
+
+func (h *highlightJSONWriter) Write(p []byte) (n int, err error) {
+	var decoded map[string]any
+	err = json.Unmarshal(p, &decoded)
+	if err != nil {
+		//Unsupported JSON let's not fail
+		return h.wrapped.Write(p)
+	}
+	var decision Decision
+	if h.colourDecider != nil {
+		decision = h.colourDecider.Decide(decoded)
+	}
+	if decision.Style != nil && decision.Key == "" {
+		//Not decided by a field so the decision is for the whole object
+		return decision.Style.Write(h.wrapped, p)
+	}
+	var decidedPath Path
+	if decision.Style != nil {
+		decidedPath = pathOrKey(decision.Key)
+	}
+
+	var tokens = make([]token, 0)
+	err = scanTokens(p, func(t token) {
+		tokens = append(tokens, t)
+	})
+	if err != nil {
+		return h.wrapped.Write(p)
+	}
+
+	var last int
+	for _, t := range tokens {
+		_, err = h.wrapped.Write(p[last:t.start])
+		if err != nil {
+			return last, err
+		}
+		if decision.Style != nil && decidedPath.matches(t.path) {
+			_, err = decision.Style.Write(h.wrapped, p[t.start:t.end])
+		} else if c := h.styles.get(t.kind); c != nil {
+			_, err = ColorStyle{Color: c}.Write(h.wrapped, p[t.start:t.end])
+		} else {
+			_, err = h.wrapped.Write(p[t.start:t.end])
+		}
+		if err != nil {
+			return t.start, err
+		}
+		last = t.end
+	}
+	_, err = h.wrapped.Write(p[last:])
+	if err != nil {
+		return last, err
+	}
+	return len(p), nil
+}
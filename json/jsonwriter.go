@@ -4,9 +4,12 @@ Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
 package jsonwriter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
@@ -19,6 +22,9 @@ type enclosedWriter struct {
 	//Bytes used for enclosing opening and closing (e.g. {})
 	braceBytes []byte
 
+	//Bytes used for enclosing arrays (e.g. []), only used with arrayWriter
+	bracketBytes []byte
+
 	//Byte used for declaring literals (e.g. `"`)
 	literalByte byte
 
@@ -27,14 +33,16 @@ type enclosedWriter struct {
 
 	//writer that is called with slices that are possible json dicts
 	embracedWriter io.Writer
-}
 
-type ColourDecider interface {
-	decide(m map[string]any) *color.Color
+	//Optional writer that is called with slices that are possible json arrays
+	arrayWriter io.Writer
 }
 
 type mapBasedColourDecider struct {
-	m map[string]map[any][]*color.Color
+	//Rules in the order they were given, the first one that matches wins.
+	//Rules that only differ in colour are merged into one rule that rotates
+	//over their colours.
+	rules []*compiledRule
 
 	i int
 	//Do not consider cases when matching
@@ -45,55 +53,46 @@ func NewMapBasedColourDecider(ignoreCase bool, c ...JSONColor) mapBasedColourDec
 	var d = mapBasedColourDecider{}
 	d.ignoreCase = ignoreCase
 
-	d.m = map[string]map[any][]*color.Color{}
 	for _, ci := range c {
-		colourMap, ok := d.m[ci.Key]
 		s, isStringValue := ci.Value.(string)
-		var val any
 		if d.ignoreCase && isStringValue {
-			val = strings.ToLower(s)
-		} else {
-			val = ci.Value
+			ci.Value = strings.ToLower(s)
 		}
-		if !ok {
-			cm := map[any][]*color.Color{}
-
-			cm[val] = ci.Color
-			d.m[ci.Key] = cm
-		} else {
-			colourMap[val] = append(colourMap[val], ci.Color...)
+		if ci.Operator == "" {
+			ci.Operator = OpEquals
+		}
+		idx := slices.IndexFunc(d.rules, func(r *compiledRule) bool {
+			return r.Key == ci.Key && r.Operator == ci.Operator && r.Value == ci.Value
+		})
+		if idx != -1 {
+			d.rules[idx].Color = append(d.rules[idx].Color, ci.Color...)
+			continue
 		}
+		ci.Color = slices.Clone(ci.Color)
+		d.rules = append(d.rules, compileRule(ci, d.ignoreCase))
 	}
 	return d
 }
 
-func (d *mapBasedColourDecider) decide(m map[string]any) *color.Color {
-	for key, value := range m {
-		mapToColour, ok := (d.m)[key]
-		if ok {
-			s, isStringValue := value.(string)
-			var colours []*color.Color
-			var colourOk bool
-			if isStringValue && d.ignoreCase {
-				colours, colourOk = mapToColour[strings.ToLower(s)]
-			} else {
-				colours, colourOk = mapToColour[value]
-			}
-			if colourOk {
-				chosenColor := colours[d.i%len(colours)]
-				d.i = (d.i + 1) % 100000
-				return chosenColor
+func (d *mapBasedColourDecider) Decide(m map[string]any) Decision {
+	for _, r := range d.rules {
+		if r.matches(m, d.ignoreCase) && len(r.Color) > 0 {
+			chosenColor := r.Color[d.i%len(r.Color)]
+			d.i = (d.i + 1) % 100000
+			return Decision{
+				Style: ColorStyle{Color: chosenColor},
+				Rule:  r.JSONColor,
+				Key:   r.Key,
 			}
 		}
 	}
-	return nil
+	return Decision{}
 }
 
 type possibleJSONWriter struct {
 	wrapped io.Writer
 
-	//A mapping to decide the colour. it maps key names to a mapping to decide on colour
-	//if there are multiple matches there is no guarantee on a winner.
+	//Decides the colour of an object
 	colourDecider ColourDecider
 }
 
@@ -104,77 +103,175 @@ func (j *possibleJSONWriter) Write(p []byte) (n int, err error) {
 		//Unsupported JSON let's not fail
 		return j.wrapped.Write(p)
 	}
-	c := j.colourDecider.decide(decoded)
-	if c == nil {
+	decision := j.colourDecider.Decide(decoded)
+	if decision.Style == nil {
 		return j.wrapped.Write(p)
 	}
-	c.SetWriter(j.wrapped)
-	n, err = j.wrapped.Write(p)
-	c.UnsetWriter(j.wrapped)
-
-	return n, err
+	return decision.Style.Write(j.wrapped, p)
 }
 
 type JSONColor struct {
-	Key   string
-	Value any
-	Color []*color.Color
+	//A key or a path to a nested value (see ParsePath)
+	Key string
+	//How the value of the field is compared to Value, empty means OpEquals
+	Operator Operator
+	Value    any
+	Color    []*color.Color
+}
+
+func (c JSONColor) String() string {
+	if c.Operator == OpExists {
+		return c.Key + string(OpExists)
+	}
+	op := c.Operator
+	if op == "" {
+		op = OpEquals
+	}
+	return fmt.Sprintf("%s%s%v", c.Key, op, c.Value)
 }
 
 func NewJSONWriter(w io.Writer, c ColourDecider) io.Writer {
+	return NewEnclosedWriter(w, &possibleJSONWriter{
+		wrapped:       w,
+		colourDecider: c,
+	})
+}
+
+// NewEnclosedWriter writes everything that looks like a JSON object (a {}
+// enclosed span) to embracedWriter and all other bytes straight to w. Objects
+// inside arrays (e.g. [{...},{...}]) are each written to embracedWriter.
+func NewEnclosedWriter(w io.Writer, embracedWriter io.Writer) io.Writer {
 	return &enclosedWriter{
-		wrapped:     w,
-		braceBytes:  []byte{byte('{'), byte('}')},
-		literalByte: byte('"'),
-		escapeByte:  byte('\\'),
-		embracedWriter: &possibleJSONWriter{
-			wrapped:       w,
-			colourDecider: c,
-		},
+		wrapped:        w,
+		braceBytes:     []byte{byte('{'), byte('}')},
+		literalByte:    byte('"'),
+		escapeByte:     byte('\\'),
+		embracedWriter: embracedWriter,
 	}
 }
 
-func (j *enclosedWriter) Write(p []byte) (n int, err error) {
-	n = 0
-	var idxOpeningBrace int
-	curlyBraceDepth := 0
+// NewEnclosedValueWriter is like NewEnclosedWriter but writes spans that look
+// like JSON arrays (a [] enclosed span starting with a JSON value) as a whole
+// to arrayWriter.
+func NewEnclosedValueWriter(w io.Writer, embracedWriter io.Writer, arrayWriter io.Writer) io.Writer {
+	return &enclosedWriter{
+		wrapped:        w,
+		braceBytes:     []byte{byte('{'), byte('}')},
+		bracketBytes:   []byte{byte('['), byte(']')},
+		literalByte:    byte('"'),
+		escapeByte:     byte('\\'),
+		embracedWriter: embracedWriter,
+		arrayWriter:    arrayWriter,
+	}
+}
+
+// startsArray tells whether the bytes after an opening bracket look like the
+// start of a JSON array rather than text like [INFO]. As text like worker[3]
+// or footnotes[1] also looks like an array only arrays that contain an object
+// or array are taken to be JSON, see spans.
+func startsArray(rest []byte) bool {
+	rest = bytes.TrimLeft(rest, " \t")
+	return len(rest) > 0 && bytes.IndexByte([]byte("{[]\"-0123456789tfn"), rest[0]) != -1
+}
+
+// closer returns the byte that closes the container opened by c
+func (j *enclosedWriter) closer(c byte) byte {
+	if c == j.braceBytes[0] {
+		return j.braceBytes[1]
+	}
+	return j.bracketBytes[1]
+}
+
+func (j *enclosedWriter) opens(c byte) bool {
+	return c == j.braceBytes[0] || (j.arrayWriter != nil && c == j.bracketBytes[0])
+}
+
+func (j *enclosedWriter) closes(c byte) bool {
+	return c == j.braceBytes[1] || (j.arrayWriter != nil && c == j.bracketBytes[1])
+}
+
+// A {} or [] enclosed part of a write, end is the position of the closing byte
+type span struct {
+	start int
+	end   int
+
+	//Whether there is another span in it
+	nested bool
+}
+
+// spans returns the outermost balanced spans of p in order. Every byte is
+// visited once. An opening byte that is never closed or that is closed by the
+// wrong byte is just text but the balanced spans after it are still found.
+func (j *enclosedWriter) spans(p []byte) []span {
+	var matched = make([]span, 0)
+	//Positions of the opening bytes that are not closed yet
+	var openers = make([]int, 0)
+	//Whether a span got closed within the opener at the same index
+	var nested = make([]bool, 0)
 	var inLiteral bool
 
 	for i := 0; i < len(p); i++ {
-		switch p[i] {
-		case j.braceBytes[0]:
-			if inLiteral {
-				continue
-			}
-			if curlyBraceDepth == 0 && i != 0 {
-				//First curly brace what comes before must be written unprocessed
-				ni, err := j.wrapped.Write(p[n:i])
-				n += ni
-				if err != nil {
-					return n, err
-				}
-			}
-			curlyBraceDepth += 1
-			if curlyBraceDepth == 1 {
-				idxOpeningBrace = i
-			}
-		case j.braceBytes[1]:
-			if inLiteral {
+		switch {
+		case p[i] == j.escapeByte:
+			i += 1
+		case p[i] == j.literalByte:
+			inLiteral = !inLiteral
+		case inLiteral:
+		case j.opens(p[i]):
+			openers = append(openers, i)
+			nested = append(nested, false)
+		case len(openers) > 0 && j.closes(p[i]):
+			top := openers[len(openers)-1]
+			if j.closer(p[top]) != p[i] {
+				//Mismatched so none of the open ones can be closed anymore
+				openers = openers[:0]
+				nested = nested[:0]
 				continue
 			}
-			curlyBraceDepth -= 1
-			if curlyBraceDepth == 0 {
-				ni, err := j.embracedWriter.Write(p[idxOpeningBrace : i+1])
-				n += ni
-				if err != nil {
-					return n, err
-				}
+			matched = append(matched, span{start: top, end: i, nested: nested[len(nested)-1]})
+			openers = openers[0 : len(openers)-1]
+			nested = nested[0 : len(nested)-1]
+			if len(nested) > 0 {
+				nested[len(nested)-1] = true
 			}
-		case j.literalByte:
-			inLiteral = !inLiteral
-		case j.escapeByte:
-			i += 1
-		default:
+		}
+	}
+
+	//Inner spans are closed before the ones around them
+	sort.Slice(matched, func(a, b int) bool { return matched[a].start < matched[b].start })
+	var outermost = make([]span, 0, len(matched))
+	var next int
+	for _, s := range matched {
+		if s.start < next {
+			continue
+		}
+		if p[s.start] != j.braceBytes[0] && (!s.nested || !startsArray(p[s.start+1:])) {
+			//Text like [INFO] or worker[3] which can still contain spans
+			continue
+		}
+		outermost = append(outermost, s)
+		next = s.end + 1
+	}
+	return outermost
+}
+
+func (j *enclosedWriter) Write(p []byte) (n int, err error) {
+	n = 0
+	for _, s := range j.spans(p) {
+		//What comes before the span must be written unprocessed
+		ni, err := j.wrapped.Write(p[n:s.start])
+		n += ni
+		if err != nil {
+			return n, err
+		}
+		var writer = j.embracedWriter
+		if p[s.start] != j.braceBytes[0] {
+			writer = j.arrayWriter
+		}
+		ni, err = writer.Write(p[s.start : s.end+1])
+		n += ni
+		if err != nil {
+			return n, err
 		}
 	}
 	if n < len(p) {
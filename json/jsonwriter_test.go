@@ -5,10 +5,14 @@ package jsonwriter_test
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
+	c "github.com/pvbouwel/sp/color"
 	jsonwriter "github.com/pvbouwel/sp/json"
 )
 
@@ -205,3 +209,321 @@ func TestJSONTrafficFunkeyValues(t *testing.T) {
 		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, line)
 	}
 }
+
+func TestJSONNestedPaths(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a decider with alternative nested paths
+	var nestedDecider = jsonwriter.NewMapBasedColourDecider(
+		true,
+		jsonwriter.JSONColor{Key: "log.level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+		jsonwriter.JSONColor{Key: "attributes[\"severity.text\"]", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+		jsonwriter.JSONColor{Key: "events[1].level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+	)
+
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"{\"log\":{\"level\":\"ERROR\"}}", "\x1b[38;2;255;0;0m{\"log\":{\"level\":\"ERROR\"}}\x1b[0m"},
+		{"{\"attributes\":{\"severity.text\":\"error\"}}", "\x1b[38;2;255;0;0m{\"attributes\":{\"severity.text\":\"error\"}}\x1b[0m"},
+		{"{\"events\":[{},{\"level\":\"error\"}]}", "\x1b[38;2;255;0;0m{\"events\":[{},{\"level\":\"error\"}]}\x1b[0m"},
+		{"{\"level\":\"error\"}", "{\"level\":\"error\"}"},
+	}
+	for i, test := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write the JSON
+		w := jsonwriter.NewJSONWriter(rb, &nestedDecider)
+		_, err := w.Write([]byte(test.input))
+		if err != nil {
+			t.Errorf("%d: Encountered error when writing msg: %s", i, err)
+		}
+
+		//THEN it is coloured if one of the paths matches
+		if rb.String() != test.expected {
+			t.Errorf("\n%d: Expected:%s\nGot     :%s", i, test.expected, rb.String())
+		}
+	}
+}
+
+func TestJSONRulePriority(t *testing.T) {
+	//Given a decider where the level rule comes before the component rule
+	var decider = jsonwriter.NewMapBasedColourDecider(
+		false,
+		jsonwriter.JSONColor{Key: "level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+		jsonwriter.JSONColor{Key: "component", Value: "db", Color: []*color.Color{color.RGB(0, 0, 255)}},
+	)
+
+	for i := 0; i < 20; i++ {
+		//WHEN both rules match
+		decision := decider.Decide(map[string]any{"component": "db", "level": "error", "msg": "x"})
+
+		//THEN the first rule always wins
+		if decision.Rule == nil || decision.Rule.String() != "level=error" {
+			t.Errorf("%d: Expected rule level=error got %v", i, decision.Rule)
+			t.FailNow()
+		}
+	}
+
+	//WHEN no rules match
+	decision := decider.Decide(map[string]any{"level": "info"})
+
+	//THEN there is no rule nor colour
+	if decision.Rule != nil || decision.Style != nil {
+		t.Errorf("Expected no decision got %v", decision)
+	}
+}
+
+func TestJSONRuleOperators(t *testing.T) {
+	var expressions = []string{"status>=500", "status>=400", "latency_ms>1000", "msg~time(out)?", "path^=/api", "error?", "ok=true"}
+	var rules = make([]jsonwriter.JSONColor, len(expressions))
+	for i, expr := range expressions {
+		rule, err := jsonwriter.ParseRule(expr)
+		if err != nil {
+			t.Errorf("%d: Could not parse rule %s: %s", i, expr, err)
+			t.FailNow()
+		}
+		rule.Color = []*color.Color{color.RGB(i, i, i)}
+		rules[i] = rule
+	}
+	//Given a decider with rules using different operators
+	var decider = jsonwriter.NewMapBasedColourDecider(false, rules...)
+
+	var tests = []struct {
+		input    map[string]any
+		expected string
+	}{
+		{map[string]any{"status": 503.0}, "status>=500"},
+		{map[string]any{"status": 404.0}, "status>=400"},
+		{map[string]any{"status": 200.0, "latency_ms": 1000.5}, "latency_ms>1000"},
+		{map[string]any{"msg": "request timeout"}, "msg~time(out)?"},
+		{map[string]any{"path": "/api/v1"}, "path^=/api"},
+		{map[string]any{"error": nil}, "error?"},
+		{map[string]any{"ok": true}, "ok=true"},
+	}
+	for i, test := range tests {
+		//WHEN deciding
+		decision := decider.Decide(test.input)
+
+		//THEN the expected rule matched
+		if decision.Rule == nil || decision.Rule.String() != test.expected {
+			t.Errorf("%d: Expected rule %s got %v", i, test.expected, decision.Rule)
+		}
+	}
+
+	//WHEN nothing matches there is no decision
+	decision := decider.Decide(map[string]any{"status": 200.0, "ok": false, "path": "/health"})
+	if decision.Rule != nil {
+		t.Errorf("Expected no rule got %v", decision.Rule)
+	}
+
+	//WHEN a rule is invalid parsing fails
+	for _, invalid := range []string{"status>=abc", "msg~(", "a..b=c"} {
+		_, err := jsonwriter.ParseRule(invalid)
+		if err == nil {
+			t.Errorf("Expected error for rule %s", invalid)
+		}
+	}
+}
+
+// requestIDDecider is a decider implemented outside of the package
+type requestIDDecider struct {
+	c *color.Color
+}
+
+func (d *requestIDDecider) Decide(m map[string]any) jsonwriter.Decision {
+	if _, ok := m["request_id"]; !ok {
+		return jsonwriter.Decision{}
+	}
+	return jsonwriter.Decision{Style: jsonwriter.ColorStyle{Color: d.c}, Key: "request_id"}
+}
+
+func TestJSONCustomDecider(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a registered custom decider
+	jsonwriter.RegisterColourDecider("request-id", func(config jsonwriter.DeciderConfig) (jsonwriter.ColourDecider, error) {
+		return &requestIDDecider{c: config.Palette[0]}, nil
+	})
+	decider, err := jsonwriter.NewColourDecider("request-id", jsonwriter.DeciderConfig{Palette: []*color.Color{color.RGB(0, 0, 255)}})
+	if err != nil {
+		t.Errorf("Could not create registered decider: %s", err)
+		t.FailNow()
+	}
+
+	//WHEN we write JSON with it
+	rb := new(bytes.Buffer)
+	w := jsonwriter.NewJSONWriter(rb, decider)
+	_, err = w.Write([]byte("{\"request_id\":\"abc\"} {\"other\":1}"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN its decisions are applied
+	expectedLine := "\x1b[38;2;0;0;255m{\"request_id\":\"abc\"}\x1b[0m {\"other\":1}"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+
+	//WHEN an unknown decider is requested THEN it fails
+	_, err = jsonwriter.NewColourDecider("unknown", jsonwriter.DeciderConfig{})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown decider")
+	}
+}
+
+func TestJSONHashDecider(t *testing.T) {
+	//Given a hash decider with alternative keys
+	palette := []*color.Color{color.RGB(255, 0, 0), color.RGB(0, 255, 0), color.RGB(0, 0, 255)}
+	decider, err := jsonwriter.NewColourDecider(jsonwriter.HashDecider, jsonwriter.DeciderConfig{Palette: palette, Keys: []string{"trace_id", "span.trace"}})
+	if err != nil {
+		t.Errorf("Could not create hash decider: %s", err)
+		t.FailNow()
+	}
+
+	testCases := []struct {
+		name        string
+		object      map[string]any
+		expectedKey string
+		expected    string
+	}{
+		{"first key", map[string]any{"trace_id": "abc", "span": map[string]any{"trace": "def"}}, "trace_id", "abc"},
+		{"same value", map[string]any{"trace_id": "abc", "msg": "other"}, "trace_id", "abc"},
+		{"object value skipped", map[string]any{"trace_id": map[string]any{"a": "abc"}, "span": map[string]any{"trace": "def"}}, "span.trace", "def"},
+		{"array value skipped", map[string]any{"trace_id": []any{"abc"}, "span": map[string]any{"trace": "def"}}, "span.trace", "def"},
+		{"null value skipped", map[string]any{"trace_id": nil, "span": map[string]any{"trace": "def"}}, "span.trace", "def"},
+		{"number", map[string]any{"trace_id": float64(42)}, "trace_id", "42"},
+		{"missing keys", map[string]any{"msg": "abc"}, "", ""},
+		{"only skipped values", map[string]any{"trace_id": nil, "span": map[string]any{"trace": []any{}}}, "", ""},
+	}
+	for _, tc := range testCases {
+		//WHEN it decides on an object
+		decision := decider.Decide(tc.object)
+
+		//THEN the first usable key decides and equal values get the same colour
+		if decision.Key != tc.expectedKey {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.name, tc.expectedKey, decision.Key)
+		}
+		if tc.expectedKey == "" {
+			if decision.Style != nil || decision.Rule != nil {
+				t.Errorf("%s: expected no decision got %+v", tc.name, decision)
+			}
+			continue
+		}
+		expectedStyle := jsonwriter.ColorStyle{Color: c.HashColor(palette, []byte(tc.expected))}
+		if decision.Style != expectedStyle {
+			t.Errorf("%s\nExpected:%+v\nGot     :%+v", tc.name, expectedStyle, decision.Style)
+		}
+		expectedRule := fmt.Sprintf("hash(%s=%s)", tc.expectedKey, tc.expected)
+		if decision.Rule == nil || decision.Rule.String() != expectedRule {
+			t.Errorf("%s\nExpected:%s\nGot     :%v", tc.name, expectedRule, decision.Rule)
+		}
+	}
+
+	//WHEN the hash decider misses colours or keys THEN it fails
+	for _, config := range []jsonwriter.DeciderConfig{{Keys: []string{"trace_id"}}, {Palette: palette}} {
+		_, err = jsonwriter.NewColourDecider(jsonwriter.HashDecider, config)
+		if err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestJSONHighlight(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given highlight styles and a decider on a nested key
+	styles := jsonwriter.HighlightStyles{Key: color.RGB(0, 0, 255), String: color.RGB(0, 255, 0)}
+	decider := jsonwriter.NewMapBasedColourDecider(
+		false,
+		jsonwriter.JSONColor{Key: "log.level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+	)
+
+	//WHEN we write JSON objects with the highlight writer
+	rb := new(bytes.Buffer)
+	w := jsonwriter.NewHighlightJSONWriter(rb, &decider, styles)
+	_, err := w.Write([]byte("a {\"log\":{\"level\":\"error\"},\"n\":1} b {\"s\":\"x\"}"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN only the deciding key and value get the decision colour, others are highlighted and unstyled kinds are plain
+	key := func(s string) string { return "\x1b[38;2;0;0;255m\"" + s + "\"\x1b[0m" }
+	str := func(s string) string { return "\x1b[38;2;0;255;0m\"" + s + "\"\x1b[0m" }
+	red := func(s string) string { return "\x1b[38;2;255;0;0m\"" + s + "\"\x1b[0m" }
+	expectedLine := "a {" + key("log") + ":{" + red("level") + ":" + red("error") + "}," + key("n") + ":1} b {" + key("s") + ":" + str("x") + "}"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%q\nGot     :%q", expectedLine, rb.String())
+	}
+}
+
+func TestJSONArrayElements(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a decider on the level
+	decider := jsonwriter.NewMapBasedColourDecider(
+		false,
+		jsonwriter.JSONColor{Key: "level", Value: "info", Color: []*color.Color{color.RGB(0, 255, 0)}},
+		jsonwriter.JSONColor{Key: "level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+	)
+
+	//WHEN we write an array of events
+	rb := new(bytes.Buffer)
+	w := jsonwriter.NewJSONWriter(rb, &decider)
+	_, err := w.Write([]byte("[{\"level\":\"error\"},{\"level\":\"info\"},{\"level\":\"debug\"}]"))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN every element is decided on its own
+	expectedLine := "[\x1b[38;2;255;0;0m{\"level\":\"error\"}\x1b[0m,\x1b[38;2;0;255;0m{\"level\":\"info\"}\x1b[0m,{\"level\":\"debug\"}]"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+}
+
+func TestJSONUnbalancedBracesLinear(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a long line of unbalanced braces and brackets followed by an object
+	unbalanced := strings.Repeat("{[", 200000)
+	line := unbalanced + "{\"level\":\"error\"}"
+	decider := jsonwriter.NewMapBasedColourDecider(
+		false,
+		jsonwriter.JSONColor{Key: "level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+	)
+
+	//WHEN it is written through JSON aware writers
+	for _, tc := range []struct {
+		newWriter      func(io.Writer) io.Writer
+		expectedSuffix string
+	}{
+		{func(w io.Writer) io.Writer { return jsonwriter.NewJSONWriter(w, &decider) }, "\x1b[38;2;255;0;0m{\"level\":\"error\"}\x1b[0m"},
+		{func(w io.Writer) io.Writer {
+			return jsonwriter.NewReformatWriter(w, jsonwriter.FormatOptions{Compact: true})
+		}, "{\"level\":\"error\"}"},
+	} {
+		rb := new(bytes.Buffer)
+		start := time.Now()
+		_, err := tc.newWriter(rb).Write([]byte(line))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+
+		//THEN the time it takes grows linearly and the object is still found
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Writing %d bytes of unbalanced braces took %s", len(line), elapsed)
+		}
+		expectedLine := unbalanced + tc.expectedSuffix
+		if rb.String() != expectedLine {
+			t.Errorf("\nExpected:...%q\nGot     :...%q", expectedLine[len(unbalanced)-4:], rb.String()[max(0, len(unbalanced)-4):])
+		}
+	}
+}
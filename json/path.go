@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A step in a path which is either a key of an object or an index of an array
+type pathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Path points to a value nested inside decoded JSON. It is written like
+// log.level, resource.attributes["service.name"] or events[0].name and can
+// optionally start with $.
+type Path struct {
+	raw      string
+	elements []pathElement
+}
+
+func ParsePath(s string) (Path, error) {
+	var p = Path{raw: s}
+	rest := strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if len(rest) == 0 || rest[0] == '.' || rest[0] == '[' {
+				return p, fmt.Errorf("invalid path %s: empty key", s)
+			}
+		case '[':
+			closeIdx := strings.IndexByte(rest, ']')
+			if len(rest) > 1 && (rest[1] == '"' || rest[1] == '\'') {
+				//A quoted key which can contain dots and brackets
+				closeQuote := strings.IndexByte(rest[2:], rest[1])
+				if closeQuote == -1 || len(rest) < closeQuote+4 || rest[closeQuote+3] != ']' {
+					return p, fmt.Errorf("invalid path %s: unterminated quoted key", s)
+				}
+				p.elements = append(p.elements, pathElement{key: rest[2 : closeQuote+2]})
+				rest = rest[closeQuote+4:]
+				continue
+			}
+			if closeIdx == -1 {
+				return p, fmt.Errorf("invalid path %s: missing ]", s)
+			}
+			index, err := strconv.Atoi(rest[1:closeIdx])
+			if err != nil || index < 0 {
+				return p, fmt.Errorf("invalid path %s: invalid index %s", s, rest[1:closeIdx])
+			}
+			p.elements = append(p.elements, pathElement{index: index, isIndex: true})
+			rest = rest[closeIdx+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			p.elements = append(p.elements, pathElement{key: rest[0:end]})
+			rest = rest[end:]
+		}
+	}
+	if len(p.elements) == 0 {
+		return p, fmt.Errorf("invalid path %s: no keys", s)
+	}
+	return p, nil
+}
+
+// pathOrKey parses s as a path and otherwise treats it as a plain key
+func pathOrKey(s string) Path {
+	p, err := ParsePath(s)
+	if err != nil {
+		return Path{raw: s, elements: []pathElement{{key: s}}}
+	}
+	return p
+}
+
+func (p Path) String() string {
+	return p.raw
+}
+
+// Lookup returns the value the path points to in a decoded JSON object. A key
+// that literally equals the whole path (e.g. "service.name") takes precedence.
+func (p Path) Lookup(m map[string]any) (any, bool) {
+	if v, ok := m[p.raw]; ok {
+		return v, true
+	}
+	var current any = m
+	for _, element := range p.elements {
+		if element.isIndex {
+			a, ok := current.([]any)
+			if !ok || element.index >= len(a) {
+				return nil, false
+			}
+			current = a[element.index]
+		} else {
+			o, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			current, ok = o[element.key]
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+	return current, true
+}
+
+// matches tells whether the path points to the location described by elements
+func (p Path) matches(elements []pathElement) bool {
+	if len(elements) == 1 && !elements[0].isIndex && elements[0].key == p.raw {
+		return true
+	}
+	return slices.Equal(p.elements, elements)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	predicateAnd = "&&"
+	predicateOr  = "||"
+)
+
+// A term that is the value of a key in a list (e.g. level in (warn,error))
+var inTermRegexp = regexp.MustCompile(`^(\S+)\s+in\s*\((.*)\)$`)
+
+// Predicate tells whether a decoded JSON object is of interest. It is a
+// disjunction (||) of conjunctions (&&) of terms. A term is a rule (see
+// ParseRule) that names a key or a list membership like level in (warn,error).
+type Predicate struct {
+	raw string
+
+	//Alternatives of which all terms must match, a term matches when one of
+	//its rules does
+	alternatives [][][]*compiledRule
+
+	ignoreCase bool
+}
+
+func ParsePredicate(expr string, ignoreCase bool) (Predicate, error) {
+	var p = Predicate{raw: expr, ignoreCase: ignoreCase}
+	for _, alternative := range strings.Split(expr, predicateOr) {
+		var terms = make([][]*compiledRule, 0)
+		for _, term := range strings.Split(alternative, predicateAnd) {
+			rules, err := parseTerm(strings.TrimSpace(term), ignoreCase)
+			if err != nil {
+				return p, err
+			}
+			terms = append(terms, rules)
+		}
+		p.alternatives = append(p.alternatives, terms)
+	}
+	return p, nil
+}
+
+func parseTerm(term string, ignoreCase bool) ([]*compiledRule, error) {
+	if groups := inTermRegexp.FindStringSubmatch(term); groups != nil {
+		var rules = make([]*compiledRule, 0)
+		for _, value := range strings.Split(groups[2], ",") {
+			rule, err := ParseRule(groups[1] + string(OpEquals) + value)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, compileRule(rule, ignoreCase))
+		}
+		return rules, nil
+	}
+	rule, err := ParseRule(term)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Key == "" {
+		return nil, fmt.Errorf("invalid term %q: it does not name a key", term)
+	}
+	return []*compiledRule{compileRule(rule, ignoreCase)}, nil
+}
+
+func (p Predicate) String() string {
+	return p.raw
+}
+
+func (p Predicate) Matches(m map[string]any) bool {
+	for _, terms := range p.alternatives {
+		if p.allMatch(terms, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Predicate) allMatch(terms [][]*compiledRule, m map[string]any) bool {
+	for _, rules := range terms {
+		var matched bool
+		for _, r := range rules {
+			if r.matches(m, p.ignoreCase) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
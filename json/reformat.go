@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// FormatOptions define how JSON objects found in text are rewritten
+type FormatOptions struct {
+	//Indentation of a level when pretty printing, ignored for compact output
+	Indent string
+
+	//Write objects on a single line without insignificant whitespace
+	Compact bool
+
+	//Order the keys of objects alphabetically instead of keeping their order
+	SortKeys bool
+
+	//Keys or paths of the fields to keep in this order, all fields when empty
+	Fields []string
+
+	//Keep the fields that are not in Fields after them
+	Tail bool
+
+	//Write objects as a human readable line instead of JSON, the values of
+	//Fields are followed by key=value pairs for the tail
+	Human bool
+}
+
+func DefaultFormatOptions() FormatOptions {
+	return FormatOptions{
+		Indent: "  ",
+	}
+}
+
+// reformatJSONWriter rewrites the JSON objects it gets, anything that is not
+// valid JSON is written as is.
+type reformatJSONWriter struct {
+	wrapped io.Writer
+	options FormatOptions
+}
+
+// NewReformatWriter re-indents or compacts the JSON objects and arrays
+// embedded in the text written to it. The text around them is left alone.
+// Fields are projected per object, also for the objects in arrays.
+func NewReformatWriter(w io.Writer, options FormatOptions) io.Writer {
+	var r = &reformatJSONWriter{
+		wrapped: w,
+		options: options,
+	}
+	if len(options.Fields) > 0 || options.Human {
+		return NewEnclosedWriter(w, r)
+	}
+	return NewEnclosedValueWriter(w, r, r)
+}
+
+func (r *reformatJSONWriter) Write(p []byte) (n int, err error) {
+	formatted, err := r.options.format(p)
+	if err != nil {
+		//Unsupported JSON let's not fail
+		return r.wrapped.Write(p)
+	}
+	_, err = r.wrapped.Write(formatted)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (o FormatOptions) format(p []byte) ([]byte, error) {
+	if len(o.Fields) > 0 || o.Human {
+		projected, err := o.project(p)
+		if err != nil || o.Human {
+			return projected, err
+		}
+		p = projected
+	}
+	if o.SortKeys {
+		sorted, err := sortKeys(p)
+		if err != nil {
+			return nil, err
+		}
+		p = sorted
+	}
+	var b bytes.Buffer
+	var err error
+	if o.Compact {
+		err = json.Compact(&b, p)
+	} else {
+		err = json.Indent(&b, p, "", o.Indent)
+	}
+	return b.Bytes(), err
+}
+
+// sortKeys re-encodes a JSON document with the keys of all objects sorted.
+// Numbers keep their original text.
+func sortKeys(p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var decoded any
+	err := dec.Decode(&decoded)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	err = enc.Encode(decoded)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter_test
+
+import (
+	"bytes"
+	"testing"
+
+	jsonwriter "github.com/pvbouwel/sp/json"
+)
+
+func TestReformat(t *testing.T) {
+	var line = "2025-01-01 INFO payload={ \"b\": 1.50, \"a\": [1, \"<x>\"] } tail {bad"
+	var tests = []struct {
+		name     string
+		options  jsonwriter.FormatOptions
+		expected string
+	}{
+		{"pretty", jsonwriter.DefaultFormatOptions(), "2025-01-01 INFO payload={\n  \"b\": 1.50,\n  \"a\": [\n    1,\n    \"<x>\"\n  ]\n} tail {bad"},
+		{"compact", jsonwriter.FormatOptions{Compact: true}, "2025-01-01 INFO payload={\"b\":1.50,\"a\":[1,\"<x>\"]} tail {bad"},
+		{"sorted", jsonwriter.FormatOptions{Compact: true, SortKeys: true}, "2025-01-01 INFO payload={\"a\":[1,\"<x>\"],\"b\":1.50} tail {bad"},
+	}
+	for _, tc := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write a line with an embedded object through a reformat writer
+		w := jsonwriter.NewReformatWriter(rb, tc.options)
+		_, err := w.Write([]byte(line))
+		if err != nil {
+			t.Errorf("%s: encountered error when writing msg: %s", tc.name, err)
+		}
+
+		//THEN only the object is rewritten
+		if rb.String() != tc.expected {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.name, tc.expected, rb.String())
+		}
+	}
+}
+
+func TestReformatFields(t *testing.T) {
+	var line = "x {\"ts\":\"2025-01-01\",\"level\":\"info\",\"msg\":\"hello world\",\"err\":\"boom it\",\"n\":1.50,\"log\":{\"a\":[1]}}"
+	var tests = []struct {
+		name     string
+		options  jsonwriter.FormatOptions
+		expected string
+	}{
+		{"human", jsonwriter.FormatOptions{Fields: []string{"ts", "level", "msg", "err="}, Human: true}, "x 2025-01-01 info hello world err=\"boom it\""},
+		{"human tail", jsonwriter.FormatOptions{Fields: []string{"msg", "missing"}, Human: true, Tail: true}, "x hello world ts=2025-01-01 level=info err=\"boom it\" n=1.50 log={\"a\":[1]}"},
+		{"json", jsonwriter.FormatOptions{Fields: []string{"level", "log.a", "ts"}, Compact: true}, "x {\"level\":\"info\",\"log.a\":[1],\"ts\":\"2025-01-01\"}"},
+		{"json tail", jsonwriter.FormatOptions{Fields: []string{"n", "log"}, Compact: true, Tail: true}, "x {\"n\":1.50,\"log\":{\"a\":[1]},\"ts\":\"2025-01-01\",\"level\":\"info\",\"msg\":\"hello world\",\"err\":\"boom it\"}"},
+	}
+	for _, tc := range tests {
+		//Given a buffer to write into
+		rb := new(bytes.Buffer)
+
+		//WHEN we write a line with an embedded object through a reformat writer with fields
+		w := jsonwriter.NewReformatWriter(rb, tc.options)
+		_, err := w.Write([]byte(line))
+		if err != nil {
+			t.Errorf("%s: encountered error when writing msg: %s", tc.name, err)
+		}
+
+		//THEN the object is projected on the fields
+		if rb.String() != tc.expected {
+			t.Errorf("%s\nExpected:%s\nGot     :%s", tc.name, tc.expected, rb.String())
+		}
+	}
+}
+
+func TestReformatArrays(t *testing.T) {
+	//Given a line with text in brackets, an array of events and an unbalanced bracket
+	var line = "[INFO] worker[3] x [1] y events=[{\"b\":1}, {\"a\":[1, 2]}] [ [1], [2] ] [0 { \"x\": 1 }"
+
+	//WHEN it is compacted
+	rb := new(bytes.Buffer)
+	w := jsonwriter.NewReformatWriter(rb, jsonwriter.FormatOptions{Compact: true})
+	_, err := w.Write([]byte(line))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+
+	//THEN arrays of objects or arrays are reformatted as a whole, brackets around values are text
+	//and objects after the unbalanced bracket are still found
+	expectedLine := "[INFO] worker[3] x [1] y events=[{\"b\":1},{\"a\":[1,2]}] [[1],[2]] [0 {\"x\":1}"
+	if rb.String() != expectedLine {
+		t.Errorf("\nExpected:%s\nGot     :%s", expectedLine, rb.String())
+	}
+}
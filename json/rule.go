@@ -0,0 +1,196 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operator compares the value of a field with the value of a rule
+type Operator string
+
+const (
+	//Equal to the value, numbers and booleans are compared by their text
+	OpEquals    Operator = "="
+	OpNotEquals Operator = "!="
+	//Matches the regular expression that is the value
+	OpRegex Operator = "~"
+	//Starts with the value
+	OpPrefix         Operator = "^="
+	OpGreater        Operator = ">"
+	OpGreaterOrEqual Operator = ">="
+	OpLess           Operator = "<"
+	OpLessOrEqual    Operator = "<="
+	//The field is present, the value is not used
+	OpExists Operator = "?"
+)
+
+// Operators in the order they must be tried when parsing such that the
+// longest one is found (e.g. >= before >)
+var parseOperators = []Operator{OpNotEquals, OpPrefix, OpGreaterOrEqual, OpLessOrEqual, OpEquals, OpRegex, OpGreater, OpLess}
+
+// ParseRule parses a rule expression. That is either a plain value which must
+// be equal, key OP value (e.g. status>=500, msg~timeout, path^=/api), OP value
+// (e.g. >=500) or key? to check existence. The key is empty when the
+// expression does not name one. Spaces around the operator are ignored.
+func ParseRule(expr string) (JSONColor, error) {
+	var rule JSONColor
+	expr = strings.TrimSpace(expr)
+	opIdx := strings.IndexAny(expr, "=!~^<>")
+	if opIdx == -1 {
+		if len(expr) > 1 && strings.HasSuffix(expr, string(OpExists)) {
+			rule.Key = strings.TrimSpace(strings.TrimSuffix(expr, string(OpExists)))
+			rule.Operator = OpExists
+		} else {
+			rule.Value = expr
+			rule.Operator = OpEquals
+		}
+		return rule, rule.validate()
+	}
+	for _, op := range parseOperators {
+		if strings.HasPrefix(expr[opIdx:], string(op)) {
+			rule.Key = strings.TrimSpace(expr[0:opIdx])
+			rule.Operator = op
+			rule.Value = strings.TrimSpace(expr[opIdx+len(op):])
+			return rule, rule.validate()
+		}
+	}
+	//A lone ! or ^ is not an operator
+	rule.Value = expr
+	rule.Operator = OpEquals
+	return rule, rule.validate()
+}
+
+func (c JSONColor) validate() error {
+	if c.Key != "" {
+		if _, err := ParsePath(c.Key); err != nil {
+			return err
+		}
+	}
+	switch c.Operator {
+	case OpRegex:
+		if _, err := regexp.Compile(valueText(c.Value)); err != nil {
+			return fmt.Errorf("invalid regex in rule %s: %s", c, err)
+		}
+	case OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual:
+		if _, ok := toFloat(c.Value); !ok {
+			return fmt.Errorf("rule %s requires a number", c)
+		}
+	}
+	return nil
+}
+
+// valueText returns the text of a JSON value as it would appear in JSON
+// without the quotes of strings.
+func valueText(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// A rule ready for matching
+type compiledRule struct {
+	JSONColor
+
+	path Path
+
+	//Set for OpRegex
+	re *regexp.Regexp
+	//Set for numeric operators
+	number float64
+}
+
+func compileRule(c JSONColor, ignoreCase bool) *compiledRule {
+	if c.Operator == "" {
+		c.Operator = OpEquals
+	}
+	var r = &compiledRule{
+		JSONColor: c,
+		path:      pathOrKey(c.Key),
+	}
+	switch c.Operator {
+	case OpRegex:
+		expr := valueText(c.Value)
+		if ignoreCase {
+			expr = "(?i)" + expr
+		}
+		//An invalid regex never matches, ParseRule reports it
+		r.re, _ = regexp.Compile(expr)
+	case OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual:
+		r.number, _ = toFloat(c.Value)
+	}
+	return r
+}
+
+func (r *compiledRule) matches(m map[string]any, ignoreCase bool) bool {
+	value, ok := r.path.Lookup(m)
+	if !ok {
+		return false
+	}
+	switch r.Operator {
+	case OpExists:
+		return true
+	case OpEquals, OpNotEquals:
+		equal := value == r.Value
+		if !equal {
+			_, isStringRule := r.Value.(string)
+			if isStringRule && ignoreCase {
+				equal = strings.EqualFold(valueText(value), valueText(r.Value))
+			} else if isStringRule {
+				equal = valueText(value) == valueText(r.Value)
+			}
+		}
+		return equal == (r.Operator == OpEquals)
+	case OpPrefix:
+		if ignoreCase {
+			return strings.HasPrefix(strings.ToLower(valueText(value)), strings.ToLower(valueText(r.Value)))
+		}
+		return strings.HasPrefix(valueText(value), valueText(r.Value))
+	case OpRegex:
+		return r.re != nil && r.re.MatchString(valueText(value))
+	}
+
+	f, ok := toFloat(value)
+	if !ok {
+		return false
+	}
+	switch r.Operator {
+	case OpGreater:
+		return f > r.number
+	case OpGreaterOrEqual:
+		return f >= r.number
+	case OpLess:
+		return f < r.number
+	case OpLessOrEqual:
+		return f <= r.number
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package jsonwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+// ScalarValue is the position of a string, number, boolean or null inside a
+// JSON document.
+type ScalarValue struct {
+	//Key of the object member this is the value of, empty for array elements
+	Key string
+
+	//Position in the document, for strings this includes the quotes
+	Start int
+	End   int
+}
+
+type tokenKind int
+
+const (
+	keyToken tokenKind = iota
+	stringToken
+	numberToken
+	boolToken
+	nullToken
+)
+
+// A key or scalar value in a JSON document
+type token struct {
+	kind tokenKind
+
+	//Position in the document, for strings this includes the quotes
+	start int
+	end   int
+
+	//Path from the root of the document to the value or to the value of the key
+	path []pathElement
+}
+
+// A container that is being decoded
+type scanFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+	index     int
+}
+
+// element returns the path element of the value that is next in the frame
+func (f *scanFrame) element() pathElement {
+	if f.object {
+		return pathElement{key: f.key}
+	}
+	return pathElement{index: f.index, isIndex: true}
+}
+
+// valueDone moves the frame on to its next member
+func (f *scanFrame) valueDone() {
+	if f.object {
+		f.expectKey = true
+	} else {
+		f.index += 1
+	}
+}
+
+// scanTokens calls fn for all keys and scalar values of the JSON document p in
+// the order they appear. An error is returned if p is not valid JSON.
+func scanTokens(p []byte, fn func(t token)) error {
+	var stack = make([]*scanFrame, 0)
+	var path = make([]pathElement, 0)
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		end := int(dec.InputOffset())
+		//The offset before the token can still include separators
+		for start < end && bytes.IndexByte([]byte(" \t\r\n:,"), p[start]) != -1 {
+			start += 1
+		}
+
+		var top *scanFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.object && top.expectKey {
+			if key, ok := tok.(string); ok {
+				top.key = key
+				top.expectKey = false
+				fn(token{kind: keyToken, start: start, end: end, path: append(slices.Clone(path), top.element())})
+				continue
+			}
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				if top != nil {
+					path = append(path, top.element())
+				}
+				stack = append(stack, &scanFrame{object: t == '{', expectKey: t == '{'})
+			default:
+				stack = stack[0 : len(stack)-1]
+				if len(stack) > 0 {
+					path = path[0 : len(path)-1]
+					stack[len(stack)-1].valueDone()
+				}
+			}
+			continue
+		case string:
+			fn(token{kind: stringToken, start: start, end: end, path: valuePath(path, top)})
+		case json.Number:
+			fn(token{kind: numberToken, start: start, end: end, path: valuePath(path, top)})
+		case bool:
+			fn(token{kind: boolToken, start: start, end: end, path: valuePath(path, top)})
+		case nil:
+			fn(token{kind: nullToken, start: start, end: end, path: valuePath(path, top)})
+		}
+		if top != nil {
+			top.valueDone()
+		}
+	}
+}
+
+func valuePath(path []pathElement, top *scanFrame) []pathElement {
+	if top == nil {
+		return slices.Clone(path)
+	}
+	return append(slices.Clone(path), top.element())
+}
+
+// ScalarValues returns the positions of all scalar values of the JSON document
+// p in the order they appear. An error is returned if p is not valid JSON.
+func ScalarValues(p []byte) ([]ScalarValue, error) {
+	var values = make([]ScalarValue, 0)
+	err := scanTokens(p, func(t token) {
+		if t.kind == keyToken {
+			return
+		}
+		var value = ScalarValue{Start: t.start, End: t.end}
+		if len(t.path) > 0 && !t.path[len(t.path)-1].isIndex {
+			value.Key = t.path[len(t.path)-1].key
+		}
+		values = append(values, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
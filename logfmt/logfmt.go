@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package logfmt
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Pair is a key=value pair of a logfmt line
+type Pair struct {
+	Key string
+
+	//The value with quotes and escapes removed, a key without = has value true
+	Value any
+
+	//Positions in the line, for quoted values the value includes the quotes.
+	//ValueStart equals ValueEnd for a key without value.
+	KeyStart   int
+	KeyEnd     int
+	ValueStart int
+	ValueEnd   int
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// Parse tokenises a logfmt line (e.g. ts=1 level=info msg="a \"quoted\" msg").
+// The second return value tells whether the line has at least one key=value
+// pair and therefore looks like logfmt.
+func Parse(line []byte) ([]Pair, bool) {
+	var pairs = make([]Pair, 0)
+	var hasValues bool
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i += 1
+		}
+		if i == len(line) {
+			return pairs, hasValues
+		}
+		var p = Pair{KeyStart: i}
+		for i < len(line) && !isSpace(line[i]) && line[i] != '=' {
+			i += 1
+		}
+		p.KeyEnd = i
+		p.Key = string(line[p.KeyStart:p.KeyEnd])
+		if i == len(line) || line[i] != '=' {
+			p.Value = true
+			p.ValueStart, p.ValueEnd = i, i
+			pairs = append(pairs, p)
+			continue
+		}
+		hasValues = hasValues || p.Key != ""
+		i += 1
+		p.ValueStart = i
+		if i < len(line) && line[i] == '"' {
+			i = quotedEnd(line, i)
+			p.ValueEnd = i
+			unquoted, err := strconv.Unquote(string(line[p.ValueStart:p.ValueEnd]))
+			if err != nil {
+				//Unterminated or invalid escapes, keep what is between the quotes
+				unquoted = string(bytes.Trim(line[p.ValueStart:p.ValueEnd], `"`))
+			}
+			p.Value = unquoted
+		} else {
+			for i < len(line) && !isSpace(line[i]) {
+				i += 1
+			}
+			p.ValueEnd = i
+			p.Value = string(line[p.ValueStart:p.ValueEnd])
+		}
+		pairs = append(pairs, p)
+	}
+}
+
+// quotedEnd returns the position after the closing quote of the quoted value
+// that starts at start, or the end of the line when it is not closed.
+func quotedEnd(line []byte, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i += 1
+		case '"':
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// ToMap returns the pairs as a map like a decoded JSON object. For keys that
+// appear multiple times the last value wins.
+func ToMap(pairs []Pair) map[string]any {
+	var m = make(map[string]any, len(pairs))
+	for _, p := range pairs {
+		if p.Key != "" {
+			m[p.Key] = p.Value
+		}
+	}
+	return m
+}
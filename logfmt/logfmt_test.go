@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package logfmt_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+	jsonwriter "github.com/pvbouwel/sp/json"
+	"github.com/pvbouwel/sp/logfmt"
+)
+
+func TestParse(t *testing.T) {
+	//WHEN a logfmt line with quoted values, escapes and a bare key is parsed
+	line := `ts=1 level=info msg="a \"quoted\" msg" ok empty= err="unterminated`
+	pairs, ok := logfmt.Parse([]byte(line))
+
+	//THEN it is logfmt and all pairs are found
+	if !ok {
+		t.Errorf("Expected %s to be logfmt", line)
+	}
+	expected := map[string]any{"ts": "1", "level": "info", "msg": `a "quoted" msg`, "ok": true, "empty": "", "err": "unterminated"}
+	if got := logfmt.ToMap(pairs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("\nExpected:%v\nGot     :%v", expected, got)
+	}
+	if line[pairs[2].ValueStart:pairs[2].ValueEnd] != `"a \"quoted\" msg"` {
+		t.Errorf("Unexpected position of quoted value: %s", line[pairs[2].ValueStart:pairs[2].ValueEnd])
+	}
+
+	//WHEN plain text is parsed THEN it is not logfmt
+	_, ok = logfmt.Parse([]byte("hello world"))
+	if ok {
+		t.Errorf("Expected plain text not to be logfmt")
+	}
+}
+
+func TestLogfmtWriter(t *testing.T) {
+	//Given color is to be done
+	color.NoColor = false
+
+	//Given a decider with JSON color rules
+	decider := jsonwriter.NewMapBasedColourDecider(
+		false,
+		jsonwriter.JSONColor{Key: "level", Value: "error", Color: []*color.Color{color.RGB(255, 0, 0)}},
+		jsonwriter.JSONColor{Key: "latency_ms", Operator: jsonwriter.OpGreater, Value: "200", Color: []*color.Color{color.RGB(0, 0, 255)}},
+	)
+	var tests = []struct {
+		name     string
+		styles   logfmt.Styles
+		line     string
+		expected string
+	}{
+		{"whole line", logfmt.Styles{}, "level=error msg=x", "\x1b[38;2;255;0;0mlevel=error msg=x\x1b[0m"},
+		{"numeric rule", logfmt.Styles{}, "level=info latency_ms=300", "\x1b[38;2;0;0;255mlevel=info latency_ms=300\x1b[0m"},
+		{"no match", logfmt.Styles{}, "level=info", "level=info"},
+		{"separately", logfmt.Styles{Key: color.New(color.FgBlue)}, "level=error msg=\"x y\"",
+			"\x1b[38;2;255;0;0mlevel\x1b[0m=\x1b[38;2;255;0;0merror\x1b[0m \x1b[34mmsg\x1b[0m=\"x y\""},
+	}
+	for _, tc := range tests {
+		//WHEN a line is written
+		rb := new(bytes.Buffer)
+		w := logfmt.NewLogfmtWriter(rb, &decider, tc.styles)
+		_, err := w.Write([]byte(tc.line))
+		if err != nil {
+			t.Errorf("%s: encountered error when writing msg: %s", tc.name, err)
+		}
+
+		//THEN it is colored according to the rules
+		if rb.String() != tc.expected {
+			t.Errorf("%s\nExpected:%q\nGot     :%q", tc.name, tc.expected, rb.String())
+		}
+	}
+}
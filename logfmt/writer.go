@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package logfmt
+
+import (
+	"io"
+
+	"github.com/fatih/color"
+	jsonwriter "github.com/pvbouwel/sp/json"
+)
+
+// Styles are the colours of keys and values, nil leaves them alone
+type Styles struct {
+	Key   *color.Color
+	Value *color.Color
+}
+
+type logfmtWriter struct {
+	wrapped io.Writer
+
+	//Decides the colour of a line based on its pairs, can be nil when styles
+	//are given
+	colourDecider jsonwriter.ColourDecider
+
+	styles Styles
+}
+
+// NewLogfmtWriter colours logfmt lines. The pairs of a line are given to the
+// colour decider as if they were a JSON object. Without key and value styles
+// the decision colours the whole line. With them keys and values are coloured
+// separately and the pair that led to the decision gets its colour instead.
+// Lines that are not logfmt are written as is.
+func NewLogfmtWriter(w io.Writer, c jsonwriter.ColourDecider, styles Styles) io.Writer {
+	return &logfmtWriter{
+		wrapped:       w,
+		colourDecider: c,
+		styles:        styles,
+	}
+}
+
+func (l *logfmtWriter) Write(p []byte) (n int, err error) {
+	pairs, ok := Parse(p)
+	if !ok {
+		return l.wrapped.Write(p)
+	}
+	var decision jsonwriter.Decision
+	if l.colourDecider != nil {
+		decision = l.colourDecider.Decide(ToMap(pairs))
+	}
+	separately := l.styles.Key != nil || l.styles.Value != nil
+	if !separately || (decision.Style != nil && decision.Key == "") {
+		if decision.Style == nil {
+			return l.wrapped.Write(p)
+		}
+		return decision.Style.Write(l.wrapped, p)
+	}
+
+	var last int
+	for _, pair := range pairs {
+		keyStyle, valueStyle := l.style(l.styles.Key), l.style(l.styles.Value)
+		if decision.Style != nil && pair.Key == decision.Key {
+			keyStyle, valueStyle = decision.Style, decision.Style
+		}
+		for _, span := range []struct {
+			start, end int
+			style      jsonwriter.Style
+		}{
+			{last, pair.KeyStart, nil},
+			{pair.KeyStart, pair.KeyEnd, keyStyle},
+			{pair.KeyEnd, pair.ValueStart, nil},
+			{pair.ValueStart, pair.ValueEnd, valueStyle},
+		} {
+			if span.start == span.end {
+				continue
+			}
+			if span.style == nil {
+				_, err = l.wrapped.Write(p[span.start:span.end])
+			} else {
+				_, err = span.style.Write(l.wrapped, p[span.start:span.end])
+			}
+			if err != nil {
+				return span.start, err
+			}
+		}
+		last = pair.ValueEnd
+	}
+	_, err = l.wrapped.Write(p[last:])
+	if err != nil {
+		return last, err
+	}
+	return len(p), nil
+}
+
+func (l *logfmtWriter) style(c *color.Color) jsonwriter.Style {
+	if c == nil {
+		return nil
+	}
+	return jsonwriter.ColorStyle{Color: c}
+}
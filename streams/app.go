@@ -3,6 +3,22 @@ Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
 */
 package streams
 
+import "io"
+
 type App interface {
 	Run() int
 }
+
+// Flusher is implemented by writers that hold back part of their input, e.g.
+// a line until its newline arrives. Flush writes it once no more input comes.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes w if it holds back input.
+func Flush(w io.Writer) error {
+	if f, ok := w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package streams
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Lines up to this size are passed on in a single write. Longer lines are
+// passed on in chunks of this size to keep memory usage bounded.
+const lineChunkSize = 1024 * 1024
+
+// Written instead of the remainder of a line that exceeds the maximum length
+const truncatedMarker = "…[truncated]"
+
+// lineLimit writes lines fragment per fragment and truncates them once they
+// exceed maxLength.
+type lineLimit struct {
+	//Maximum number of bytes of a line that are passed on, 0 means no limit
+	maxLength int
+
+	//Bytes of the current line that were passed on
+	written int
+
+	truncated bool
+}
+
+func newLineLimit(maxLength int) *lineLimit {
+	return &lineLimit{
+		maxLength: max(maxLength, 0),
+	}
+}
+
+// writeFragment passes on (part of) a line without its newline.
+func (l *lineLimit) writeFragment(w io.Writer, fragment []byte) error {
+	if l.truncated || len(fragment) == 0 {
+		return nil
+	}
+	if l.maxLength > 0 && l.written+len(fragment) > l.maxLength {
+		l.truncated = true
+		cut := l.maxLength - l.written
+		for cut > 0 && !utf8.RuneStart(fragment[cut]) {
+			//Do not split a multi-byte character
+			cut -= 1
+		}
+		_, err := w.Write(fragment[0:cut])
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(truncatedMarker))
+		return err
+	}
+	n, err := w.Write(fragment)
+	l.written += n
+	return err
+}
+
+// endLine writes the newline and starts a new line.
+func (l *lineLimit) endLine(w io.Writer) error {
+	l.written = 0
+	l.truncated = false
+	_, err := w.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package streams
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// lineWriter frames arbitrary chunks (e.g. reads from a pipe) into lines. Like
+// the piped app it writes the content of a line and its newline separately such
+// that the wrapped writer never gets a line that is split over multiple writes.
+// A partial line is only passed on when no newline arrived within flushTimeout
+// or when it gets too long to keep in memory.
+//
+// Line writers that end up on the same output (e.g. stdout and stderr of an
+// app shown in a terminal) share a lineLock. It is held from the content of a
+// line up to and including its newline such that lines of both do not mix.
+type lineWriter struct {
+	wrapped io.Writer
+
+	//Held while writing a line, shared with writers to the same output
+	lineLock sync.Locker
+
+	limit *lineLimit
+
+	//How long a partial line may be held back. Zero or less means until Flush
+	flushTimeout time.Duration
+
+	mu    sync.Mutex
+	buf   []byte
+	timer *time.Timer
+
+	//An error from a timed flush which is reported on the next Write or Flush
+	err error
+}
+
+// NewLineWriter creates a line writer, lineLock may be nil if w is not shared.
+func NewLineWriter(w io.Writer, lineLock sync.Locker, flushTimeout time.Duration, maxLineLength int) *lineWriter {
+	if lineLock == nil {
+		lineLock = &sync.Mutex{}
+	}
+	return &lineWriter{
+		wrapped:      w,
+		lineLock:     lineLock,
+		limit:        newLineLimit(maxLineLength),
+		flushTimeout: flushTimeout,
+	}
+}
+
+func (l *lineWriter) Write(p []byte) (n int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	//Whether the partial line is new in which case it gets the full timeout
+	newPartial := len(l.buf) == 0
+	l.buf = append(l.buf, p...)
+	var start int
+	for {
+		idx := bytes.IndexByte(l.buf[start:], '\n')
+		if idx == -1 {
+			break
+		}
+		err = l.writeLine(l.buf[start : start+idx])
+		start += idx + 1
+		newPartial = true
+		if err != nil {
+			l.buf = l.buf[:copy(l.buf, l.buf[start:])]
+			return len(p), err
+		}
+	}
+	l.buf = l.buf[:copy(l.buf, l.buf[start:])]
+	if len(l.buf) >= lineChunkSize {
+		err = l.flushPartial()
+		if err != nil {
+			return len(p), err
+		}
+	}
+
+	if len(l.buf) == 0 {
+		l.stopTimer()
+	} else if l.flushTimeout > 0 && newPartial {
+		if l.timer == nil {
+			l.timer = time.AfterFunc(l.flushTimeout, l.timedFlush)
+		} else {
+			l.timer.Reset(l.flushTimeout)
+		}
+	}
+	return len(p), nil
+}
+
+func (l *lineWriter) writeLine(line []byte) error {
+	l.lineLock.Lock()
+	defer l.lineLock.Unlock()
+	err := l.limit.writeFragment(l.wrapped, line)
+	if err != nil {
+		return err
+	}
+	return l.limit.endLine(l.wrapped)
+}
+
+func (l *lineWriter) stopTimer() {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+func (l *lineWriter) timedFlush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = l.flushPartial()
+	}
+}
+
+func (l *lineWriter) flushPartial() error {
+	if len(l.buf) == 0 {
+		return nil
+	}
+	l.lineLock.Lock()
+	defer l.lineLock.Unlock()
+	err := l.limit.writeFragment(l.wrapped, l.buf)
+	l.buf = l.buf[:0]
+	return err
+}
+
+// Flush passes on a pending partial line. It must be called once no more
+// writes will happen.
+func (l *lineWriter) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopTimer()
+	if l.err != nil {
+		return l.err
+	}
+	return l.flushPartial()
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package streams_test
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pvbouwel/sp/streams"
+)
+
+// recordingWriter keeps every write separately so framing can be verified
+type recordingWriter struct {
+	writes []string
+}
+
+func (r *recordingWriter) Write(p []byte) (int, error) {
+	r.writes = append(r.writes, string(p))
+	return len(p), nil
+}
+
+func expectWrites(t *testing.T, expected []string, got []string) {
+	if len(expected) != len(got) {
+		t.Errorf("\nExpected:%q\nGot     :%q", expected, got)
+		return
+	}
+	for i := range expected {
+		if expected[i] != got[i] {
+			t.Errorf("\nExpected:%q\nGot     :%q", expected, got)
+			return
+		}
+	}
+}
+
+func TestLineWriterJoinsChunks(t *testing.T) {
+	//Given a line writer without flush timeout
+	rw := &recordingWriter{}
+	w := streams.NewLineWriter(rw, nil, 0, 0)
+
+	//WHEN an epoch is split over multiple writes
+	for _, chunk := range []string{"ts=17000", "00000\nsecond ", "line\nno newline"} {
+		_, err := w.Write([]byte(chunk))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+	}
+	expectWrites(t, []string{"ts=1700000000", "\n", "second line", "\n"}, rw.writes)
+
+	//WHEN flushing the partial line is written
+	err := w.Flush()
+	if err != nil {
+		t.Errorf("Encountered error when flushing: %s", err)
+	}
+	expectWrites(t, []string{"ts=1700000000", "\n", "second line", "\n", "no newline"}, rw.writes)
+}
+
+func TestLineWriterFlushTimeout(t *testing.T) {
+	//Given a line writer with a short flush timeout
+	rw := &recordingWriter{}
+	w := streams.NewLineWriter(rw, nil, 10*time.Millisecond, 0)
+
+	//WHEN a partial line is written and no newline follows
+	_, err := w.Write([]byte("prompt> "))
+	if err != nil {
+		t.Errorf("Encountered error when writing msg: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	//THEN the partial line got written anyway
+	err = w.Flush()
+	if err != nil {
+		t.Errorf("Encountered error when flushing: %s", err)
+	}
+	expectWrites(t, []string{"prompt> "}, rw.writes)
+}
+
+func TestLineWriterTruncatesLongLines(t *testing.T) {
+	//Given a line writer with a maximum line length
+	rw := &recordingWriter{}
+	w := streams.NewLineWriter(rw, nil, 0, 5)
+
+	//WHEN a line that is too long is written in multiple chunks
+	for _, chunk := range []string{"abc", "defgh", "ij\nshort\n"} {
+		_, err := w.Write([]byte(chunk))
+		if err != nil {
+			t.Errorf("Encountered error when writing msg: %s", err)
+		}
+	}
+
+	//THEN only the start of the long line is kept followed by a marker
+	expectWrites(t, []string{"abcde", "…[truncated]", "\n", "short", "\n"}, rw.writes)
+}
+
+func TestLineWritersShareLineLock(t *testing.T) {
+	//Given two line writers to the same output sharing a line lock
+	rw := &recordingWriter{}
+	lineLock := &sync.Mutex{}
+	writers := []io.Writer{
+		streams.NewLineWriter(rw, lineLock, 0, 0),
+		streams.NewLineWriter(rw, lineLock, 0, 0),
+	}
+
+	//WHEN both write lines concurrently
+	var wg sync.WaitGroup
+	for i, w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				_, err := w.Write([]byte(fmt.Sprintf("line of writer %d\n", i)))
+				if err != nil {
+					t.Errorf("Encountered error when writing msg: %s", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	//THEN every line is directly followed by its newline
+	if len(rw.writes) != 4000 {
+		t.Errorf("\nExpected:%d writes\nGot     :%d writes", 4000, len(rw.writes))
+	}
+	for i := 0; i+1 < len(rw.writes); i += 2 {
+		if rw.writes[i] == "\n" || rw.writes[i+1] != "\n" {
+			t.Errorf("\nExpected:%q\nGot     :%q", []string{rw.writes[i], "\n"}, rw.writes[i:i+2])
+			return
+		}
+	}
+}
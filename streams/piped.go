@@ -5,53 +5,88 @@ package streams
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
 // A stream processing app
 type pipedApp struct {
 	stdOutWriter io.Writer
+
+	//An optional second input which is processed by stdErrWriter
+	errInput     io.Reader
+	stdErrWriter io.Writer
+
+	//Lines longer than this get truncated, 0 means no limit
+	maxLineLength int
 }
 
-func NewPipedApp(stdOutWriter io.Writer) App {
+func NewPipedApp(stdOutWriter io.Writer, errInput io.Reader, stdErrWriter io.Writer, maxLineLength int) App {
 	return &pipedApp{
-		stdOutWriter: stdOutWriter,
+		stdOutWriter:  stdOutWriter,
+		errInput:      errInput,
+		stdErrWriter:  stdErrWriter,
+		maxLineLength: maxLineLength,
 	}
 }
 
 func (a *pipedApp) Run() int {
+	var wg sync.WaitGroup
+	var stdinErr, errInputErr error
+
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stdinErr = copyLines(os.Stdin, a.stdOutWriter, a.maxLineLength)
+		}()
+	}
+	if a.errInput != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errInputErr = copyLines(a.errInput, a.stdErrWriter, a.maxLineLength)
+		}()
+	}
+	wg.Wait()
 
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			_, err := a.stdOutWriter.Write(scanner.Bytes())
-			if err != nil {
-				_, err := os.Stderr.Write([]byte(err.Error()))
-				if err != nil {
-					panic(fmt.Sprintf("Could not write to stderr: %s", err))
-				}
-				return 1
-			}
-			_, err = a.stdOutWriter.Write([]byte("\n"))
-			if err != nil {
-				_, err = os.Stderr.Write([]byte(err.Error()))
-				if err != nil {
-					panic(fmt.Sprintf("Could not write to stderr: %s", err))
-				}
-				return 1
-			}
+	err := errors.Join(stdinErr, errInputErr)
+	if err != nil {
+		_, err = os.Stderr.Write([]byte(err.Error()))
+		if err != nil {
+			panic(fmt.Sprintf("Could not write to stderr: %s", err))
 		}
-		if err := scanner.Err(); err != nil {
-			_, err = os.Stderr.Write([]byte(err.Error()))
+		return 1
+	}
+	return 0
+}
+
+// copyLines writes every line of r to w followed by a separate newline write.
+// Lines that do not fit the read buffer are written in multiple fragments.
+func copyLines(r io.Reader, w io.Writer, maxLineLength int) error {
+	reader := bufio.NewReaderSize(r, lineChunkSize)
+	limit := newLineLimit(maxLineLength)
+	for {
+		fragment, isPrefix, err := reader.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = limit.writeFragment(w, fragment)
+		if err != nil {
+			return err
+		}
+		if !isPrefix {
+			err = limit.endLine(w)
 			if err != nil {
-				panic(fmt.Sprintf("Could not write to stderr: %s", err))
+				return err
 			}
-			return 1
 		}
-
 	}
-	return 0
 }
@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package streams_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pvbouwel/sp/streams"
+)
+
+func TestPipedAppLongLines(t *testing.T) {
+	longLine := strings.Repeat("a", 3*1024*1024+100)
+	input := longLine + "\nshort\n"
+	testCases := []struct {
+		maxLineLength int
+		expectedLong  string
+	}{
+		{0, longLine},
+		{1000, longLine[0:1000] + "…[truncated]"},
+	}
+	for _, tc := range testCases {
+		//Given a line of several MiB on stdin and on a second input
+		stdin, stdinWriter, err := os.Pipe()
+		if err != nil {
+			t.Errorf("Could not create pipe: %s", err)
+			t.FailNow()
+		}
+		go func() {
+			_, _ = stdinWriter.Write([]byte(input))
+			stdinWriter.Close()
+		}()
+		origStdin := os.Stdin
+		os.Stdin = stdin
+		stdOut := &recordingWriter{}
+		stdErr := &recordingWriter{}
+		app := streams.NewPipedApp(stdOut, bytes.NewReader([]byte(input)), stdErr, tc.maxLineLength)
+
+		//WHEN the app runs
+		code := app.Run()
+		os.Stdin = origStdin
+		stdin.Close()
+
+		//THEN both inputs are passed on in bounded fragments with the newline separately
+		if code != 0 {
+			t.Errorf("\nExpected:%d\nGot     :%d", 0, code)
+		}
+		for _, writes := range [][]string{stdOut.writes, stdErr.writes} {
+			if len(writes) < 4 {
+				t.Errorf("Expected the long line, its newline and the short line got %d writes", len(writes))
+				continue
+			}
+			long := writes[0 : len(writes)-3]
+			for _, fragment := range long {
+				if len(fragment) > 1024*1024 {
+					t.Errorf("Fragment of %d bytes is larger than the read buffer", len(fragment))
+				}
+			}
+			if got := strings.Join(long, ""); got != tc.expectedLong {
+				t.Errorf("\nExpected:%d bytes ending in %q\nGot     :%d bytes ending in %q", len(tc.expectedLong), tc.expectedLong[len(tc.expectedLong)-20:], len(got), got[max(0, len(got)-20):])
+			}
+			expectWrites(t, []string{"\n", "short", "\n"}, writes[len(writes)-3:])
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !unix
+
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+
+package streams
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// jobControl is a no-op as process groups are a unix concept.
+type jobControl struct{}
+
+func setProcessGroup(prog *exec.Cmd) *jobControl {
+	return &jobControl{}
+}
+
+func (j *jobControl) started() {}
+
+func (j *jobControl) restore() {}
+
+// forwardSignals keeps sp alive on an interrupt until the returned function is
+// called. The console already delivers the interrupt to the app, so sp waits
+// for the app to decide on its exit code.
+func forwardSignals(prog *exec.Cmd) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	return func() {
+		signal.Stop(sigs)
+	}
+}
+
+func exitCode(exitErr *exec.ExitError) int {
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,132 @@
+//go:build unix
+
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+
+package streams
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Signals that sp receives and passes on to the process group of the app
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// jobControl keeps the app in a process group of its own working with the job
+// control of the shell. The shell only knows about sp, so when the app gets
+// stopped (e.g. Ctrl-Z) sp takes the terminal back and stops itself and when
+// sp gets continued (fg or bg) it continues the app.
+type jobControl struct {
+	prog *exec.Cmd
+
+	//The terminal of which the app is the foreground group, -1 if none
+	ttyFd int
+
+	//The process group of sp which owned the terminal
+	spGroup int
+}
+
+// setProcessGroup starts the app in a process group of its own such that
+// signals reach everything it spawned. If sp owns the terminal the group of
+// the app becomes the foreground group so interactive apps can use it.
+func setProcessGroup(prog *exec.Cmd) *jobControl {
+	j := &jobControl{prog: prog, ttyFd: -1}
+	prog.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if !canFollowStops {
+		//The terminal would stay with a stopped app
+		return j
+	}
+	ttyFd := int(os.Stdin.Fd())
+	foregroundGroup, err := unix.IoctlGetInt(ttyFd, unix.TIOCGPGRP)
+	if err != nil || foregroundGroup != unix.Getpgrp() {
+		//Not a terminal or sp is running in the background
+		return j
+	}
+	prog.SysProcAttr.Foreground = true
+	prog.SysProcAttr.Ctty = ttyFd
+	j.ttyFd = ttyFd
+	j.spGroup = foregroundGroup
+	return j
+}
+
+// started must be called once the app runs.
+func (j *jobControl) started() {
+	if j.ttyFd != -1 {
+		go followStops(j.prog.Process.Pid, j.suspend)
+	}
+}
+
+// suspend stops sp like the app got stopped and continues the app once sp
+// gets continued.
+func (j *jobControl) suspend() {
+	j.takeTerminal(j.spGroup)
+	continued := make(chan os.Signal, 1)
+	signal.Notify(continued, syscall.SIGCONT)
+	defer signal.Stop(continued)
+	_ = syscall.Kill(-j.spGroup, syscall.SIGTSTP)
+	//The stop is asynchronous, SIGCONT only arrives once sp really stopped.
+	<-continued
+	//With fg the shell gave the terminal back to sp
+	foregroundGroup, err := unix.IoctlGetInt(j.ttyFd, unix.TIOCGPGRP)
+	if err == nil && foregroundGroup == j.spGroup {
+		j.takeTerminal(j.prog.Process.Pid)
+	}
+	_ = syscall.Kill(-j.prog.Process.Pid, syscall.SIGCONT)
+}
+
+// restore hands the terminal back to sp unless sp got moved to the background
+// in the meantime (bg) in which case the shell owns it.
+func (j *jobControl) restore() {
+	if j.ttyFd == -1 {
+		return
+	}
+	foregroundGroup, err := unix.IoctlGetInt(j.ttyFd, unix.TIOCGPGRP)
+	if err == nil && (j.prog.Process == nil || foregroundGroup == j.prog.Process.Pid) {
+		j.takeTerminal(j.spGroup)
+	}
+}
+
+func (j *jobControl) takeTerminal(group int) {
+	//sp can be a background process at this point so it must ignore SIGTTOU
+	//otherwise changing the foreground group would stop it.
+	signal.Ignore(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(j.ttyFd, unix.TIOCSPGRP, group)
+}
+
+// forwardSignals passes signals received by sp on to the process group of the
+// started app until the returned function is called.
+func forwardSignals(prog *exec.Cmd) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, forwardedSignals...)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				_ = syscall.Kill(-prog.Process.Pid, sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// exitCode follows the shell convention of 128+signal number for apps that
+// were killed by a signal.
+func exitCode(exitErr *exec.ExitError) int {
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
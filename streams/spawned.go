@@ -4,10 +4,13 @@ Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
 package streams
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sync"
+	"time"
 )
 
 // A stream processing app
@@ -17,14 +20,22 @@ type spawnedApp struct {
 
 	appName string
 	appArgs []string
+
+	//How long a partial line of the app is held back waiting for its newline
+	lineFlushTimeout time.Duration
+
+	//Lines of the app longer than this get truncated, 0 means no limit
+	maxLineLength int
 }
 
-func NewSpawnedApp(stdOutWriter io.Writer, StdErrWriter io.Writer, appName string, AppArgs []string) App {
+func NewSpawnedApp(stdOutWriter io.Writer, StdErrWriter io.Writer, appName string, AppArgs []string, lineFlushTimeout time.Duration, maxLineLength int) App {
 	return &spawnedApp{
-		stdOutWriter: stdOutWriter,
-		stdErrWriter: StdErrWriter,
-		appName:      appName,
-		appArgs:      AppArgs,
+		stdOutWriter:     stdOutWriter,
+		stdErrWriter:     StdErrWriter,
+		appName:          appName,
+		appArgs:          AppArgs,
+		lineFlushTimeout: lineFlushTimeout,
+		maxLineLength:    maxLineLength,
 	}
 }
 
@@ -35,10 +46,40 @@ func (a *spawnedApp) Run() int {
 		return 1
 	}
 	prog := exec.Command(appPath, a.appArgs...)
-	prog.Stdout = a.stdOutWriter
-	prog.Stderr = a.stdErrWriter
-	err = prog.Run()
+	prog.Stdin = os.Stdin
+	// The pipes of the app deliver arbitrary chunks, frame them into lines such
+	// that writers see the same input as in piped mode.
+	// Both typically end up in the same terminal so they share a line lock.
+	lineLock := &sync.Mutex{}
+	stdOut := NewLineWriter(a.stdOutWriter, lineLock, a.lineFlushTimeout, a.maxLineLength)
+	stdErr := NewLineWriter(a.stdErrWriter, lineLock, a.lineFlushTimeout, a.maxLineLength)
+	prog.Stdout = stdOut
+	prog.Stderr = stdErr
+	jobs := setProcessGroup(prog)
+	err = prog.Start()
+	if err != nil {
+		jobs.restore()
+		fmt.Fprintf(os.Stderr, "Could not start app %s: %s", appPath, err)
+		return 1
+	}
+	jobs.started()
+	stopForwarding := forwardSignals(prog)
+	err = prog.Wait()
+	stopForwarding()
+	jobs.restore()
+
+	// The app is done so writers must not wait for the rest of a line anymore
+	flushErr := errors.Join(stdOut.Flush(), stdErr.Flush(), Flush(a.stdOutWriter), Flush(a.stdErrWriter))
+	if flushErr != nil {
+		fmt.Fprintf(os.Stderr, "Could not write output of %s: %s", appPath, flushErr)
+		return 1
+	}
 	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			//The app decides on the exit code
+			return exitCode(exitErr)
+		}
 		fmt.Fprintf(os.Stderr, "Spawned app %s got error: %s", appPath, err)
 		return 1
 	}
@@ -0,0 +1,114 @@
+//go:build unix
+
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+package streams_test
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pvbouwel/sp/streams"
+)
+
+func TestSpawnedAppExitCode(t *testing.T) {
+	testCases := []struct {
+		script         string
+		expectedCode   int
+		expectedOutput string
+	}{
+		{"echo ok", 0, "ok\n"},
+		{"echo failing; exit 7", 7, "failing\n"},
+		//Killed by a signal gives 128+signal like a shell does
+		{"echo terminated; kill -TERM $$", 128 + 15, "terminated\n"},
+		{"kill -KILL $$", 128 + 9, ""},
+	}
+	for _, tc := range testCases {
+		//Given a spawned shell running the script
+		stdOut := new(bytes.Buffer)
+		stdErr := new(bytes.Buffer)
+		app := streams.NewSpawnedApp(stdOut, stdErr, "sh", []string{"-c", tc.script}, 0, 0)
+
+		//WHEN it is run
+		code := app.Run()
+
+		//THEN sp exits like the app did
+		if code != tc.expectedCode {
+			t.Errorf("\nExpected:%d\nGot     :%d", tc.expectedCode, code)
+		}
+		if stdOut.String() != tc.expectedOutput {
+			t.Errorf("\nExpected:%q\nGot     :%q", tc.expectedOutput, stdOut.String())
+		}
+		if stdErr.Len() != 0 {
+			t.Errorf("\nExpected:%q\nGot     :%q", "", stdErr.String())
+		}
+	}
+}
+
+// holdingWriter holds back everything until it gets flushed
+type holdingWriter struct {
+	bytes.Buffer
+	flushed string
+}
+
+func (h *holdingWriter) Flush() error {
+	h.flushed = h.String()
+	return nil
+}
+
+func TestSpawnedAppFlushesWriters(t *testing.T) {
+	//Given a spawned app which ends without a newline
+	stdOut := &holdingWriter{}
+	app := streams.NewSpawnedApp(stdOut, &holdingWriter{}, "sh", []string{"-c", "printf 'last line'"}, 0, 0)
+
+	//WHEN it is run
+	code := app.Run()
+
+	//THEN the writers got flushed after the last output
+	if code != 0 {
+		t.Errorf("\nExpected:%d\nGot     :%d", 0, code)
+	}
+	if stdOut.flushed != "last line" {
+		t.Errorf("\nExpected:%q\nGot     :%q", "last line", stdOut.flushed)
+	}
+}
+
+func TestSpawnedAppForwardsSignalsToGrandchildren(t *testing.T) {
+	//Given the test survives a SIGTERM sent to itself
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	//Given a spawned shell which waits for a grandchild that keeps its output open
+	app := streams.NewSpawnedApp(new(bytes.Buffer), new(bytes.Buffer), "sh", []string{"-c", "sleep 30; true"}, 0, 0)
+
+	//WHEN sp gets terminated while the app runs
+	done := make(chan int)
+	start := time.Now()
+	go func() {
+		done <- app.Run()
+	}()
+	var code int
+	for waiting := true; waiting; {
+		select {
+		case code = <-done:
+			waiting = false
+		case <-time.After(50 * time.Millisecond):
+			//Repeated as the app might not have started yet
+			_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		}
+	}
+
+	//THEN the grandchild got terminated too so sp does not wait for it
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Terminating the app took %s", elapsed)
+	}
+	if code != 128+15 {
+		t.Errorf("\nExpected:%d\nGot     :%d", 128+15, code)
+	}
+}
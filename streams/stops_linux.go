@@ -0,0 +1,30 @@
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+
+package streams
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+const canFollowStops = true
+
+// followStops calls suspend every time the process pid gets stopped. It only
+// waits for stops, reaping the process is left to exec.Cmd.Wait after which
+// waiting fails and followStops returns.
+func followStops(pid int, suspend func()) {
+	for {
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WSTOPPED, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		suspend()
+	}
+}
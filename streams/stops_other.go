@@ -0,0 +1,13 @@
+//go:build unix && !linux
+
+/*
+Copyright © 2025 Peter Van Bouwel <https://github.com/pvbouwel>
+*/
+
+package streams
+
+// Waiting for stops only is linux specific, elsewhere the app does not get the
+// terminal.
+const canFollowStops = false
+
+func followStops(pid int, suspend func()) {}